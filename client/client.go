@@ -0,0 +1,205 @@
+// Package client drives a running whatsapp-dev instance from Go (integration) tests.
+//
+//	c := client.New("http://localhost:1090")
+//	defer c.Close()
+//
+//	conversation, err := c.StartConversation(ctx, client.StartConversationOptions{PhoneNumber: "31612345678", Message: "Hi"})
+//	reply, err := c.WaitForMessage(ctx, client.InConversation(conversation.ID))
+//	_, err = c.ClickButton(ctx, reply.Button("Yes"))
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Client talks to the admin api (/api) of a whatsapp-dev instance
+type Client struct {
+	// BaseURL is the url of the whatsapp-dev instance, for example http://localhost:1090
+	BaseURL string
+	// Username and Password are used if the instance requires basic auth
+	Username string
+	Password string
+	// HTTPClient is used for all requests, defaults to http.DefaultClient
+	HTTPClient *http.Client
+
+	listenLock sync.Mutex
+	listener   *listener
+}
+
+// New creates a client for the whatsapp-dev instance at baseURL
+func New(baseURL string) *Client {
+	return &Client{
+		BaseURL: strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+// Close stops listening for messages
+func (c *Client) Close() error {
+	c.listenLock.Lock()
+	defer c.listenLock.Unlock()
+
+	if c.listener == nil {
+		return nil
+	}
+	err := c.listener.close()
+	c.listener = nil
+	return err
+}
+
+// StartConversationOptions are the options for StartConversation
+type StartConversationOptions struct {
+	// BusinessPhoneNumberID is the database id of the business phone number, 0 uses the default phone number
+	BusinessPhoneNumberID uint   `json:"businessPhoneNumberId,omitempty"`
+	PhoneNumber           string `json:"phoneNumber"`
+	ContactName           string `json:"contactName,omitempty"`
+	// Message is the first message send by the user
+	Message string `json:"message"`
+	// Region is used to parse local phone numbers, empty uses the default region of the instance
+	Region string `json:"region,omitempty"`
+}
+
+// StartConversation sends the first message of a user, if there already is a conversation with the phone number the message is added to it.
+// Messages send by the business after this call can be awaited using WaitForMessage.
+func (c *Client) StartConversation(ctx context.Context, options StartConversationOptions) (*Conversation, error) {
+	err := c.listen(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	conversation := &Conversation{}
+	err = c.do(ctx, http.MethodPost, "/api/conversations", options, conversation)
+	return conversation, err
+}
+
+// SendAsUser sends a text message from the user of the conversation to the business
+func (c *Client) SendAsUser(ctx context.Context, conversationID uint, message string) (*Conversation, error) {
+	err := c.listen(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	conversation := &Conversation{}
+	err = c.do(ctx, http.MethodPost, fmt.Sprintf("/api/conversations/%d", conversationID), map[string]string{"message": message}, conversation)
+	return conversation, err
+}
+
+// ClickButton clicks a quick reply button of a message send by the business
+func (c *Client) ClickButton(ctx context.Context, button *Button) (*Conversation, error) {
+	if button == nil {
+		return nil, errors.New("button not found")
+	}
+
+	err := c.listen(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	conversation := &Conversation{}
+	err = c.do(ctx, http.MethodPost, fmt.Sprintf("/api/conversations/%d/btnQuickReply/%d", button.ConversationID, button.ID), nil, conversation)
+	return conversation, err
+}
+
+// Conversations returns all conversations including their messages
+func (c *Client) Conversations(ctx context.Context) ([]Conversation, error) {
+	conversations := []Conversation{}
+	err := c.do(ctx, http.MethodGet, "/api/conversations", nil, &conversations)
+	return conversations, err
+}
+
+// Conversation returns the conversation with id including its messages
+func (c *Client) Conversation(ctx context.Context, id uint) (*Conversation, error) {
+	conversations, err := c.Conversations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for idx, conversation := range conversations {
+		if conversation.ID == id {
+			return &conversations[idx], nil
+		}
+	}
+	return nil, fmt.Errorf("conversation %d not found", id)
+}
+
+// Templates returns all message templates
+func (c *Client) Templates(ctx context.Context) ([]Template, error) {
+	templates := []Template{}
+	err := c.do(ctx, http.MethodGet, "/api/templates", nil, &templates)
+	return templates, err
+}
+
+// Reset removes all conversations, faults, expectations and rate limit counters of the instance and cancels its pending bot responses and webhooks.
+// Messages received by the client that have not been awaited are dropped.
+func (c *Client) Reset(ctx context.Context) error {
+	err := c.do(ctx, http.MethodPost, "/api/reset", nil, nil)
+	if err != nil {
+		return err
+	}
+
+	c.listenLock.Lock()
+	if c.listener != nil {
+		c.listener.clear()
+	}
+	c.listenLock.Unlock()
+	return nil
+}
+
+// do sends a request to the admin api and decodes the json response into result if it's not nil
+func (c *Client) do(ctx context.Context, method string, path string, body any, result any) error {
+	var bodyReader io.Reader
+	if body != nil {
+		bodyBytes, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		bodyReader = bytes.NewReader(bodyBytes)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, bodyReader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.Username != "" || c.Password != "" {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	resBody, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode >= 300 {
+		errorResponse := struct {
+			Error string `json:"error"`
+		}{}
+		if json.Unmarshal(resBody, &errorResponse) == nil && errorResponse.Error != "" {
+			return fmt.Errorf("%s %s: %s", method, path, errorResponse.Error)
+		}
+		return fmt.Errorf("%s %s: unexpected status %d", method, path, res.StatusCode)
+	}
+
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(resBody, result)
+}
@@ -0,0 +1,98 @@
+package client
+
+import "time"
+
+// Direction is the direction of a message as seen from the simulated user
+type Direction string
+
+const (
+	// DirectionIn are messages send by the business to the user
+	DirectionIn Direction = "in"
+	// DirectionOut are messages send by the user to the business
+	DirectionOut Direction = "out"
+)
+
+// Conversation is a conversation between a business phone number and a simulated user
+type Conversation struct {
+	ID                    uint             `json:"ID"`
+	CreatedAt             time.Time        `json:"CreatedAt"`
+	BusinessPhoneNumberID uint             `json:"businessPhoneNumberId"`
+	PhoneNumber           string           `json:"phoneNumber"`
+	ContactName           string           `json:"contactName"`
+	WaID                  *string          `json:"waId"`
+	HideProfileName       bool             `json:"hideProfileName"`
+	PhoneNumberInfo       *PhoneNumberInfo `json:"phoneNumberInfo"`
+	Messages              []Message        `json:"messages"`
+}
+
+// PhoneNumberInfo contains the metadata of the phone number of a conversation
+type PhoneNumberInfo struct {
+	E164        string `json:"e164"`
+	Country     string `json:"country"`
+	CountryName string `json:"countryName"`
+	CountryCode string `json:"countryCode"`
+	LineType    string `json:"lineType"`
+}
+
+// LastMessage returns the last message of the conversation or nil if there are no messages
+func (c *Conversation) LastMessage() *Message {
+	if len(c.Messages) == 0 {
+		return nil
+	}
+	return &c.Messages[len(c.Messages)-1]
+}
+
+// Message is a message within a conversation
+type Message struct {
+	ID             uint      `json:"ID"`
+	CreatedAt      time.Time `json:"CreatedAt"`
+	ConversationID uint      `json:"conversationId"`
+	WhatsappID     string    `json:"whatsappID"`
+	Direction      Direction `json:"direction"`
+	HeaderMessage  *string   `json:"headerMessage"`
+	Message        string    `json:"message"`
+	FooterMessage  *string   `json:"footerMessage"`
+	Timestamp      int64     `json:"timestamp"`
+	Payload        *string   `json:"payload"`
+	Buttons        []Button  `json:"buttons"`
+	DuplicateOfID  *uint     `json:"duplicateOfId"`
+}
+
+// Button returns the button with text or nil if the message has no such button
+func (m *Message) Button(text string) *Button {
+	for idx, button := range m.Buttons {
+		if button.Text == text {
+			return &m.Buttons[idx]
+		}
+	}
+	return nil
+}
+
+// Button is a quick reply button of a message
+type Button struct {
+	ID             uint    `json:"ID"`
+	ConversationID uint    `json:"conversationId"`
+	MessageID      uint    `json:"messageId"`
+	Text           string  `json:"text"`
+	Payload        *string `json:"payload"`
+}
+
+// Template is a message template
+type Template struct {
+	ID                uint             `json:"ID"`
+	BusinessAccountID uint             `json:"businessAccountId"`
+	Name              string           `json:"name"`
+	Language          string           `json:"language"`
+	Category          string           `json:"category"`
+	Header            *string          `json:"header"`
+	Body              string           `json:"body"`
+	Footer            *string          `json:"footer"`
+	Buttons           []TemplateButton `json:"templateCustomButtons"`
+}
+
+// TemplateButton is a custom button of a template
+type TemplateButton struct {
+	ID         uint   `json:"ID"`
+	TemplateID uint   `json:"templateId"`
+	Text       string `json:"text"`
+}
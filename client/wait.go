@@ -0,0 +1,176 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/fasthttp/websocket"
+)
+
+// Matcher decides if a message is the awaited message
+type Matcher func(message Message) bool
+
+// InConversation matches messages of the conversation with id
+func InConversation(id uint) Matcher {
+	return func(message Message) bool {
+		return message.ConversationID == id
+	}
+}
+
+// TextContains matches messages containing text
+func TextContains(text string) Matcher {
+	return func(message Message) bool {
+		return strings.Contains(message.Message, text)
+	}
+}
+
+// WithButton matches messages with a quick reply button with text
+func WithButton(text string) Matcher {
+	return func(message Message) bool {
+		return message.Button(text) != nil
+	}
+}
+
+// All matches messages matching all matchers
+func All(matchers ...Matcher) Matcher {
+	return func(message Message) bool {
+		for _, matcher := range matchers {
+			if matcher != nil && !matcher(message) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// WaitForMessage waits for a message send by the business that matches matcher, a nil matcher matches any message.
+// Only messages send after the client started listening are matched, the client starts listening on the first call to StartConversation, SendAsUser, ClickButton or WaitForMessage.
+// Every message is returned at most once, so calling WaitForMessage multiple times returns the messages in the order they were send.
+func (c *Client) WaitForMessage(ctx context.Context, matcher Matcher) (*Message, error) {
+	err := c.listen(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c.listenLock.Lock()
+	l := c.listener
+	c.listenLock.Unlock()
+	if l == nil {
+		return nil, errors.New("client closed")
+	}
+
+	for {
+		message, received, err := l.take(matcher)
+		if message != nil || err != nil {
+			return message, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-received:
+		}
+	}
+}
+
+// listen starts listening for messages send by the business if the client isn't listening yet
+func (c *Client) listen(ctx context.Context) error {
+	c.listenLock.Lock()
+	defer c.listenLock.Unlock()
+
+	if c.listener != nil {
+		return nil
+	}
+
+	header := http.Header{}
+	if c.Username != "" || c.Password != "" {
+		req := &http.Request{Header: header}
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+	url := "ws" + strings.TrimPrefix(c.BaseURL, "http") + "/api/events"
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, url, header)
+	if err != nil {
+		return err
+	}
+
+	c.listener = &listener{
+		conn:     conn,
+		received: make(chan struct{}),
+	}
+	go c.listener.read()
+	return nil
+}
+
+// listener collects the messages send by the business over the events websocket
+type listener struct {
+	conn *websocket.Conn
+
+	lock     sync.Mutex
+	messages []Message
+	// received is closed and replaced every time a message is received
+	received chan struct{}
+	err      error
+}
+
+func (l *listener) read() {
+	for {
+		_, payload, err := l.conn.ReadMessage()
+		if err != nil {
+			l.lock.Lock()
+			l.err = err
+			close(l.received)
+			l.lock.Unlock()
+			return
+		}
+
+		event := struct {
+			Type    string  `json:"type"`
+			Message Message `json:"message"`
+		}{}
+		if json.Unmarshal(payload, &event) != nil || event.Type != "message" {
+			continue
+		}
+		if event.Message.Direction != DirectionIn {
+			// Messages of the simulated user are not awaited
+			continue
+		}
+
+		l.lock.Lock()
+		l.messages = append(l.messages, event.Message)
+		close(l.received)
+		l.received = make(chan struct{})
+		l.lock.Unlock()
+	}
+}
+
+// take removes and returns the first message matching matcher.
+// If there is no such message the returned channel is closed once a new message is received.
+func (l *listener) take(matcher Matcher) (*Message, <-chan struct{}, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	for idx, message := range l.messages {
+		if matcher == nil || matcher(message) {
+			l.messages = append(l.messages[:idx], l.messages[idx+1:]...)
+			return &message, nil, nil
+		}
+	}
+	if l.err != nil {
+		return nil, nil, l.err
+	}
+	return nil, l.received, nil
+}
+
+func (l *listener) clear() {
+	l.lock.Lock()
+	l.messages = nil
+	l.lock.Unlock()
+}
+
+func (l *listener) close() error {
+	return l.conn.Close()
+}
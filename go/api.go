@@ -1,11 +1,22 @@
 package src
 
 import (
+	"errors"
+
 	"github.com/gofiber/fiber/v2"
+	"github.com/mjarkk/whatsapp-dev/go/controller/bots"
+	"github.com/mjarkk/whatsapp-dev/go/controller/business"
 	"github.com/mjarkk/whatsapp-dev/go/controller/conversations"
+	"github.com/mjarkk/whatsapp-dev/go/controller/expectations"
+	"github.com/mjarkk/whatsapp-dev/go/controller/faults"
 	"github.com/mjarkk/whatsapp-dev/go/controller/templates"
+	"github.com/mjarkk/whatsapp-dev/go/controller/tokens"
+	"github.com/mjarkk/whatsapp-dev/go/controller/traffic"
 	"github.com/mjarkk/whatsapp-dev/go/controller/webhooks"
 	"github.com/mjarkk/whatsapp-dev/go/controller/websocket"
+	"github.com/mjarkk/whatsapp-dev/go/lib/graph"
+	"github.com/mjarkk/whatsapp-dev/go/lib/ratelimit"
+	"github.com/mjarkk/whatsapp-dev/go/models"
 	"github.com/mjarkk/whatsapp-dev/go/state"
 )
 
@@ -26,24 +37,34 @@ func apiRoutes(r fiber.Router) {
 	r.Get("/info", func(c *fiber.Ctx) error {
 		return c.JSON(struct {
 			GraphToken         string `json:"graphToken"`
+			AppID              string `json:"appID"`
 			AppSecret          string `json:"appSecret"`
 			PhoneNumber        string `json:"phoneNumber"`
 			PhoneNumberID      string `json:"phoneNumberID"`
+			BusinessAccountID  string `json:"businessAccountID"`
 			WebhookURL         string `json:"webhookURL"`
 			WebhookVerifyToken string `json:"webhookVerifyToken"`
 		}{
 			GraphToken:         state.GraphToken.Get(),
+			AppID:              state.AppID.Get(),
 			AppSecret:          state.AppSecret.Get(),
 			PhoneNumber:        state.PhoneNumber.Get(),
 			PhoneNumberID:      state.PhoneNumberID.Get(),
+			BusinessAccountID:  state.BusinessAccountID.Get(),
 			WebhookURL:         state.WebhookURL.Get(),
 			WebhookVerifyToken: state.WebhookVerifyToken.Get(),
 		})
 	})
 
+	r.Get("/businessAccounts", business.Index)
+	r.Post("/businessAccounts", business.Create)
+	r.Post("/businessAccounts/:id/phoneNumbers", business.CreatePhoneNumber)
+	r.Put("/phoneNumbers/:id/recipients", business.UpdateRecipients)
+
 	r.Get("/conversations", conversations.Index)
 	r.Post("/conversations", conversations.Create)
 	r.Post("/conversations/:id", conversations.CreateMessage)
+	r.Patch("/conversations/:id", conversations.UpdateContact)
 	r.Post("/conversations/:id/btnQuickReply/:btnId", conversations.BtnQuickReply)
 
 	r.Get("/templates", templates.Index)
@@ -51,5 +72,95 @@ func apiRoutes(r fiber.Router) {
 	r.Patch("/templates/:id", templates.Update)
 	r.Delete("/templates/:id", templates.Delete)
 
+	r.Get("/tokens", tokens.Index)
+	r.Post("/tokens", tokens.Create)
+	r.Post("/tokens/:id/revoke", tokens.Revoke)
+	r.Delete("/tokens/:id", tokens.Delete)
+
+	r.Get("/messages/wait", conversations.WaitForMessage)
+	r.Get("/messages/:id/inspect", conversations.InspectMessage)
+	r.Get("/wamid/decode", conversations.DecodeWamid)
+
+	r.Get("/duplicates", conversations.Duplicates)
+	r.Get("/duplicates/settings", func(c *fiber.Ctx) error {
+		return c.JSON(models.Duplicates.Get())
+	})
+	r.Put("/duplicates/settings", func(c *fiber.Ctx) error {
+		config := models.DuplicateConfig{}
+		err := c.BodyParser(&config)
+		if err != nil {
+			return err
+		}
+		switch config.Mode {
+		case "":
+			config.Mode = models.DuplicateModeOff
+		case models.DuplicateModeOff, models.DuplicateModeFlag, models.DuplicateModeDedupe:
+			// Valid mode
+		default:
+			return errors.New("mode must be one of off, flag or dedupe")
+		}
+		if config.WindowSeconds < 0 {
+			return errors.New("windowSeconds cannot be negative")
+		}
+		models.Duplicates.Set(config)
+		return c.JSON(config)
+	})
+
+	r.Get("/graphVersions", func(c *fiber.Ctx) error {
+		return c.JSON(graph.Versions.Get())
+	})
+	r.Put("/graphVersions", func(c *fiber.Ctx) error {
+		versions := []graph.SupportedVersion{}
+		err := c.BodyParser(&versions)
+		if err != nil {
+			return err
+		}
+		err = graph.ValidateVersions(versions)
+		if err != nil {
+			return err
+		}
+		graph.Versions.Set(versions)
+		return c.JSON(versions)
+	})
+
+	r.Get("/errors", func(c *fiber.Ctx) error {
+		return c.JSON(graph.Catalog)
+	})
+	r.Get("/faults", faults.Index)
+	r.Post("/faults", faults.Create)
+	r.Delete("/faults/:id", faults.Delete)
+
+	r.Get("/expectations", expectations.Index)
+	r.Post("/expectations", expectations.Create)
+	r.Delete("/expectations", expectations.Clear)
+	r.Delete("/expectations/:id", expectations.Delete)
+
+	r.Get("/bots", bots.Index)
+	r.Post("/bots", bots.Create)
+	r.Delete("/bots", bots.Clear)
+	r.Delete("/bots/:id", bots.Delete)
+
+	r.Get("/traffic", traffic.Index)
+	r.Get("/traffic/har", traffic.HAR)
+	r.Delete("/traffic", traffic.Clear)
+
+	r.Get("/rateLimits", func(c *fiber.Ctx) error {
+		return c.JSON(ratelimit.Limits.Get())
+	})
+	r.Put("/rateLimits", func(c *fiber.Ctx) error {
+		limits := ratelimit.Config{}
+		err := c.BodyParser(&limits)
+		if err != nil {
+			return err
+		}
+		if limits.MessagesPerSecond < 0 || limits.PairMessages < 0 || limits.PairWindowSeconds < 0 || limits.BusinessUseCaseCalls < 0 || limits.BusinessUseCaseWindowSeconds < 0 {
+			return errors.New("rate limits cannot be negative")
+		}
+		ratelimit.Limits.Set(limits)
+		return c.JSON(limits)
+	})
+
 	r.Post("/webhook/test", webhooks.Test)
+
+	r.Post("/reset", conversations.Reset)
 }
@@ -0,0 +1,166 @@
+package batch
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/mjarkk/whatsapp-dev/go/lib/graph"
+	"github.com/mjarkk/whatsapp-dev/go/lib/traffic"
+	"github.com/valyala/fasthttp"
+)
+
+// maxBatchSize is the maximum amount of requests in a batch
+const maxBatchSize = 50
+
+type request struct {
+	Method      string `json:"method"`
+	RelativeURL string `json:"relative_url"`
+	Body        string `json:"body"`
+	Headers     []struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	} `json:"headers"`
+}
+
+type header struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type response struct {
+	Code    int      `json:"code"`
+	Headers []header `json:"headers,omitempty"`
+	Body    string   `json:"body"`
+}
+
+// Handle executes a batch of requests like POST / with the batch parameter.
+// Every request is dispatched through the routes of the app and has its own status, failures do not affect the other requests.
+func Handle(c *fiber.Ctx) error {
+	batchParam := c.FormValue("batch")
+	if batchParam == "" {
+		return graph.CustomError("(#100) The parameter batch is required.")
+	}
+
+	requests := []request{}
+	err := json.Unmarshal([]byte(batchParam), &requests)
+	if err != nil {
+		return graph.CustomError("(#100) Param batch must be a valid JSON array", err.Error())
+	}
+	if len(requests) == 0 {
+		return graph.CustomError("(#100) Param batch must be a non-empty array")
+	}
+	if len(requests) > maxBatchSize {
+		return graph.CustomError(fmt.Sprintf("(#100) Too many requests in batch message. Maximum batch size is %d", maxBatchSize))
+	}
+
+	// Relative urls without a version use the version of the batch request, or the latest version for POST /
+	version := graph.LatestVersion().String()
+	if c.Params("version") != "" {
+		version = "v" + c.Params("version")
+	}
+	includeHeaders := c.FormValue("include_headers", "true") != "false"
+
+	responses := []*response{}
+	for idx, item := range requests {
+		method := strings.ToUpper(item.Method)
+		if method == "" {
+			return graph.CustomError(fmt.Sprintf("(#100) Param batch[%d]['method'] is required", idx))
+		}
+		if item.RelativeURL == "" {
+			return graph.CustomError(fmt.Sprintf("(#100) Param batch[%d]['relative_url'] is required", idx))
+		}
+		if isBatchURL(item.RelativeURL) {
+			return graph.CustomError(fmt.Sprintf("(#100) Param batch[%d]['relative_url'] cannot be a batch request, batch requests cannot be nested", idx))
+		}
+
+		responses = append(responses, dispatch(c, version, method, item, includeHeaders))
+	}
+
+	return c.JSON(responses)
+}
+
+var versionPrefixRegex = regexp.MustCompile(`^v\d+(\.\d+)?(/|$)`)
+
+// isBatchURL returns true if the relative url points to the batch endpoint itself (/ or /vX/)
+func isBatchURL(relativeURL string) bool {
+	path, _, _ := strings.Cut(relativeURL, "?")
+	path = strings.TrimPrefix(strings.TrimSpace(path), "/")
+	path = versionPrefixRegex.ReplaceAllString(path, "")
+	return strings.Trim(path, "/") == ""
+}
+
+// dispatch executes a single request of the batch using the handler of the app
+func dispatch(c *fiber.Ctx, version string, method string, item request, includeHeaders bool) *response {
+	path := strings.TrimPrefix(item.RelativeURL, "/")
+	if !versionPrefixRegex.MatchString(path) {
+		path = version + "/" + path
+	}
+	path = "/" + path
+
+	req := fasthttp.Request{}
+	req.Header.SetMethod(method)
+	req.SetRequestURI(path)
+	// Requests in a batch inherit the access token of the batch request
+	if authorization := c.Get(fiber.HeaderAuthorization); authorization != "" {
+		req.Header.Set(fiber.HeaderAuthorization, authorization)
+	} else if accessToken := c.FormValue("access_token"); accessToken != "" {
+		req.Header.Set(fiber.HeaderAuthorization, "Bearer "+accessToken)
+	}
+	for _, itemHeader := range item.Headers {
+		req.Header.Set(itemHeader.Name, itemHeader.Value)
+	}
+	if item.Body != "" {
+		body, contentType := requestBody(item.Body)
+		req.SetBody(body)
+		req.Header.SetContentType(contentType)
+	}
+
+	ctx := fasthttp.RequestCtx{}
+	ctx.Init(&req, c.Context().RemoteAddr(), nil)
+	// The batch request itself is recorded, not the requests within it
+	ctx.SetUserValue(traffic.InternalRequestKey, true)
+	c.App().Handler()(&ctx)
+
+	res := &response{
+		Code: ctx.Response.StatusCode(),
+		Body: string(ctx.Response.Body()),
+	}
+	if includeHeaders {
+		res.Headers = []header{}
+		ctx.Response.Header.VisitAll(func(key, value []byte) {
+			res.Headers = append(res.Headers, header{Name: string(key), Value: string(value)})
+		})
+	}
+	return res
+}
+
+// requestBody converts the body of a batch request into a JSON body.
+// Batch bodies are url encoded, values containing JSON objects or arrays are embedded as JSON.
+func requestBody(body string) ([]byte, string) {
+	if json.Valid([]byte(body)) {
+		return []byte(body), fiber.MIMEApplicationJSON
+	}
+
+	values, err := url.ParseQuery(body)
+	if err != nil {
+		return []byte(body), fiber.MIMEApplicationForm
+	}
+
+	object := map[string]any{}
+	for key, value := range values {
+		rawValue := value[0]
+		trimmedValue := strings.TrimSpace(rawValue)
+		if (strings.HasPrefix(trimmedValue, "{") || strings.HasPrefix(trimmedValue, "[")) && json.Valid([]byte(trimmedValue)) {
+			object[key] = json.RawMessage(trimmedValue)
+		} else {
+			object[key] = rawValue
+		}
+	}
+
+	jsonBody, _ := json.Marshal(object)
+	return jsonBody, fiber.MIMEApplicationJSON
+}
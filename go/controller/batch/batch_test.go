@@ -0,0 +1,78 @@
+package batch
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/mjarkk/whatsapp-dev/go/lib/graph"
+)
+
+func TestIsBatchURL(t *testing.T) {
+	tests := map[string]bool{
+		"":                         true,
+		"/":                        true,
+		"?include_headers=false":   true,
+		"v19.0":                    true,
+		"/v19.0/":                  true,
+		"v19.0/?batch=[]":          true,
+		"me":                       false,
+		"1234/messages":            false,
+		"/v19.0/1234":              false,
+		"v19.0/1234/message_qrdls": false,
+		"version/1234":             false,
+	}
+
+	for relativeURL, expected := range tests {
+		if isBatchURL(relativeURL) != expected {
+			t.Errorf("isBatchURL(%q) expected %t", relativeURL, expected)
+		}
+	}
+}
+
+func TestHandleUnversionedBatch(t *testing.T) {
+	graph.Versions.Set([]graph.SupportedVersion{{Version: "v18.0"}, {Version: "v19.0"}, {Version: "v20.0", Sunset: true}})
+
+	app := fiber.New()
+	app.Post("/", Handle)
+	app.Post("/v:version/", Handle)
+	app.Post("/v:version/:phoneNumberId/messages", func(c *fiber.Ctx) error {
+		return c.SendString(c.Path())
+	})
+
+	tests := []struct {
+		path        string
+		relativeURL string
+		expected    string
+	}{
+		{path: "/", relativeURL: "1234/messages", expected: "/v19.0/1234/messages"},
+		{path: "/", relativeURL: "/1234/messages", expected: "/v19.0/1234/messages"},
+		{path: "/", relativeURL: "v18.0/1234/messages", expected: "/v18.0/1234/messages"},
+		{path: "/v18.0/", relativeURL: "1234/messages", expected: "/v18.0/1234/messages"},
+		{path: "/v18.0/", relativeURL: "/v19.0/1234/messages", expected: "/v19.0/1234/messages"},
+	}
+
+	for _, test := range tests {
+		form := url.Values{}
+		form.Set("batch", `[{"method":"POST","relative_url":"`+test.relativeURL+`"}]`)
+		form.Set("include_headers", "false")
+		req := httptest.NewRequest(fiber.MethodPost, test.path, strings.NewReader(form.Encode()))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationForm)
+
+		res, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		responses := []response{}
+		err = json.NewDecoder(res.Body).Decode(&responses)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(responses) != 1 || responses[0].Code != fiber.StatusOK || responses[0].Body != test.expected {
+			t.Errorf("POST %s with relative_url %q: expected %s, got %+v", test.path, test.relativeURL, test.expected, responses)
+		}
+	}
+}
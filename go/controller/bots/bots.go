@@ -0,0 +1,44 @@
+package bots
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/mjarkk/whatsapp-dev/go/lib/bots"
+)
+
+// Index returns all bots
+func Index(c *fiber.Ctx) error {
+	return c.JSON(bots.List())
+}
+
+// Create validates and adds a bot, it responds to the messages the business sends after this call
+func Create(c *fiber.Ctx) error {
+	bot := bots.Bot{}
+	err := c.BodyParser(&bot)
+	if err != nil {
+		return err
+	}
+
+	bot, err = bots.Add(bot)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(bot)
+}
+
+// Delete removes the bot with the id, actions it already planned are still executed
+func Delete(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return err
+	}
+
+	bots.Remove(uint(id))
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// Clear removes all bots
+func Clear(c *fiber.Ctx) error {
+	bots.Clear()
+	return c.SendStatus(fiber.StatusNoContent)
+}
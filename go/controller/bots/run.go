@@ -0,0 +1,86 @@
+package bots
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mjarkk/whatsapp-dev/go/controller/conversations"
+	"github.com/mjarkk/whatsapp-dev/go/controller/websocket"
+	. "github.com/mjarkk/whatsapp-dev/go/db"
+	"github.com/mjarkk/whatsapp-dev/go/lib/bots"
+	"github.com/mjarkk/whatsapp-dev/go/models"
+)
+
+// OnBusinessMessage lets the bots respond to a message sent by the business, the responses are sent in the background
+func OnBusinessMessage(message models.Message) {
+	conversation := models.Conversation{}
+	err := DB.First(&conversation, message.ConversationID).Error
+	if err != nil {
+		return
+	}
+
+	for _, planned := range bots.Plan(message, conversation) {
+		go func(planned bots.Planned) {
+			time.Sleep(time.Duration(planned.Action.DelayMs) * time.Millisecond)
+			if !planned.Active() {
+				return
+			}
+
+			err := execute(planned.Action, message)
+			if err != nil {
+				fmt.Printf("bot %q failed to respond: %s\n", planned.Bot, err.Error())
+			}
+		}(planned)
+	}
+}
+
+// execute sends the reply or clicks the button like the simulated user would do in the UI
+func execute(action bots.Action, message models.Message) error {
+	conversation := &models.Conversation{}
+	err := DB.First(conversation, message.ConversationID).Error
+	if err != nil {
+		return err
+	}
+
+	text := action.Reply
+	var payload *string
+	if action.Reply == "" {
+		buttons := []models.MessageButton{}
+		err = DB.Where("message_id = ?", message.ID).Order("id").Find(&buttons).Error
+		if err != nil {
+			return err
+		}
+
+		button, err := findButton(action, buttons)
+		if err != nil {
+			return err
+		}
+		text = button.Text
+		payload = button.Payload
+	}
+
+	reply, err := conversations.SendContactMessage(conversation, text, payload)
+	if err != nil {
+		return err
+	}
+
+	websocket.SendMessage(reply)
+	return nil
+}
+
+func findButton(action bots.Action, buttons []models.MessageButton) (models.MessageButton, error) {
+	if action.ClickButton != nil {
+		if *action.ClickButton >= len(buttons) {
+			return models.MessageButton{}, fmt.Errorf("message has no button with index %d", *action.ClickButton)
+		}
+		return buttons[*action.ClickButton], nil
+	}
+
+	for _, button := range buttons {
+		if button.Text == action.ClickButtonText {
+			return button, nil
+		}
+	}
+	return models.MessageButton{}, errors.New("message has no button with text " + action.ClickButtonText)
+}
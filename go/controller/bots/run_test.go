@@ -0,0 +1,52 @@
+package bots
+
+import (
+	"testing"
+
+	"github.com/mjarkk/whatsapp-dev/go/lib/bots"
+	"github.com/mjarkk/whatsapp-dev/go/models"
+)
+
+func TestFindButton(t *testing.T) {
+	buttons := []models.MessageButton{{Text: "Yes"}, {Text: "No"}, {Text: "Cancel order"}}
+	index := func(i int) *int {
+		return &i
+	}
+
+	tests := []struct {
+		name     string
+		action   bots.Action
+		expected string
+		err      string
+	}{
+		{name: "first index", action: bots.Action{ClickButton: index(0)}, expected: "Yes"},
+		{name: "last index", action: bots.Action{ClickButton: index(2)}, expected: "Cancel order"},
+		{name: "index out of range", action: bots.Action{ClickButton: index(3)}, err: "message has no button with index 3"},
+		{name: "text", action: bots.Action{ClickButtonText: "No"}, expected: "No"},
+		{name: "text is case sensitive", action: bots.Action{ClickButtonText: "no"}, err: "message has no button with text no"},
+		{name: "unknown text", action: bots.Action{ClickButtonText: "Maybe"}, err: "message has no button with text Maybe"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			button, err := findButton(test.action, buttons)
+			if test.err != "" {
+				if err == nil || err.Error() != test.err {
+					t.Fatalf("expected error %q, got %v", test.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err.Error())
+			}
+			if button.Text != test.expected {
+				t.Fatalf("expected button %q, got %q", test.expected, button.Text)
+			}
+		})
+	}
+
+	_, err := findButton(bots.Action{ClickButton: index(0)}, nil)
+	if err == nil {
+		t.Fatal("expected an error for a message without buttons")
+	}
+}
@@ -0,0 +1,202 @@
+package business
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	. "github.com/mjarkk/whatsapp-dev/go/db"
+	"github.com/mjarkk/whatsapp-dev/go/models"
+	"github.com/mjarkk/whatsapp-dev/go/utils/phonenumber"
+	"github.com/mjarkk/whatsapp-dev/go/utils/random"
+)
+
+var (
+	randomLock   sync.Mutex
+	randomSource = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+// SetRandomSource sets the random source used to generate the ids of new business accounts and phone numbers, a seeded source makes the generated ids reproducible
+func SetRandomSource(r *rand.Rand) {
+	randomLock.Lock()
+	randomSource = r
+	randomLock.Unlock()
+}
+
+func randomNumbers(size int) string {
+	randomLock.Lock()
+	defer randomLock.Unlock()
+
+	return random.Numbers(randomSource, size)
+}
+
+func Index(c *fiber.Ctx) error {
+	accounts := []models.BusinessAccount{}
+	err := DB.Model(&models.BusinessAccount{}).Preload("PhoneNumbers.Profile.ProfilePicture").Find(&accounts).Error
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(accounts)
+}
+
+func Create(c *fiber.Ctx) error {
+	request := struct {
+		WabaID string `json:"wabaId"`
+		Name   string `json:"name"`
+	}{}
+	err := c.BodyParser(&request)
+	if err != nil {
+		return err
+	}
+
+	if request.Name == "" {
+		return errors.New("name is required")
+	}
+	if request.WabaID == "" {
+		request.WabaID = randomNumbers(15)
+	}
+
+	count := int64(0)
+	err = DB.Model(&models.BusinessAccount{}).Where("waba_id = ?", request.WabaID).Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return errors.New("a business account with this id already exists")
+	}
+
+	account := models.BusinessAccount{
+		WabaID: request.WabaID,
+		Name:   request.Name,
+	}
+	err = DB.Create(&account).Error
+	if err != nil {
+		return err
+	}
+
+	err = account.CreateSampleTemplates()
+	if err != nil {
+		return err
+	}
+
+	account.PhoneNumbers = []models.BusinessPhoneNumber{}
+	return c.JSON(account)
+}
+
+func CreatePhoneNumber(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return err
+	}
+	if id < 1 {
+		return errors.New("invalid id")
+	}
+
+	request := struct {
+		PhoneNumber   string `json:"phoneNumber"`
+		PhoneNumberID string `json:"phoneNumberId"`
+		VerifiedName  string `json:"verifiedName"`
+		// Unregistered creates a number that still needs to go through the verification and registration flow
+		Unregistered bool `json:"unregistered"`
+	}{}
+	err = c.BodyParser(&request)
+	if err != nil {
+		return err
+	}
+
+	account := models.BusinessAccount{}
+	err = DB.First(&account, id).Error
+	if err != nil {
+		return err
+	}
+
+	if request.PhoneNumber == "" {
+		return errors.New("phone number is required")
+	}
+	parsedPhoneNumber, err := phonenumber.Parse(request.PhoneNumber, false)
+	if err != nil {
+		return err
+	}
+
+	if request.PhoneNumberID == "" {
+		request.PhoneNumberID = randomNumbers(15)
+	}
+	if request.VerifiedName == "" {
+		request.VerifiedName = account.Name
+	}
+
+	count := int64(0)
+	err = DB.Model(&models.BusinessPhoneNumber{}).Where("phone_number_id = ?", request.PhoneNumberID).Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return errors.New("a phone number with this id already exists")
+	}
+
+	number := models.NewBusinessPhoneNumber(account.ID, parsedPhoneNumber.Parsed, request.PhoneNumberID, request.VerifiedName)
+	if request.Unregistered {
+		number.RegistrationStatus = models.RegistrationStatusUnregistered
+		number.CodeVerificationStatus = models.CodeVerificationStatusNotVerified
+	}
+	err = DB.Create(&number).Error
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(number)
+}
+
+// UpdateRecipients sets the recipient mode and allowed recipients of a phone number
+func UpdateRecipients(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return err
+	}
+
+	request := struct {
+		RecipientMode     models.RecipientMode `json:"recipientMode"`
+		AllowedRecipients []string             `json:"allowedRecipients"`
+	}{}
+	err = c.BodyParser(&request)
+	if err != nil {
+		return err
+	}
+
+	switch request.RecipientMode {
+	case models.RecipientModeConversations, models.RecipientModeOpen, models.RecipientModeSandbox:
+		// Valid mode
+	default:
+		return errors.New("recipientMode must be one of CONVERSATIONS, OPEN or SANDBOX")
+	}
+	if len(request.AllowedRecipients) > models.MaxSandboxRecipients {
+		return fmt.Errorf("at most %d recipients can be allowed", models.MaxSandboxRecipients)
+	}
+	allowedRecipients := []string{}
+	for _, recipient := range request.AllowedRecipients {
+		parsedRecipient, err := phonenumber.Parse(recipient, false)
+		if err != nil {
+			return fmt.Errorf("invalid recipient %s: %s", recipient, err.Error())
+		}
+		allowedRecipients = append(allowedRecipients, parsedRecipient.Parsed)
+	}
+
+	number := models.BusinessPhoneNumber{}
+	err = DB.First(&number, id).Error
+	if err != nil {
+		return err
+	}
+
+	number.RecipientMode = request.RecipientMode
+	number.AllowedRecipients = allowedRecipients
+	err = DB.Select("RecipientMode", "AllowedRecipients").Updates(&number).Error
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(number)
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/mjarkk/whatsapp-dev/go/lib/webhook"
 	"github.com/mjarkk/whatsapp-dev/go/models"
 	"github.com/mjarkk/whatsapp-dev/go/utils/phonenumber"
+	"github.com/mjarkk/whatsapp-dev/go/utils/wamid"
 )
 
 func Index(c *fiber.Ctx) error {
@@ -17,14 +18,21 @@ func Index(c *fiber.Ctx) error {
 	if err != nil {
 		return err
 	}
+	for idx := range conversation {
+		conversation[idx].SetPhoneNumberInfo()
+	}
 
 	return c.JSON(conversation)
 }
 
 func Create(c *fiber.Ctx) error {
 	request := struct {
-		PhoneNumber string `json:"phoneNumber"`
-		Message     string `json:"message"`
+		BusinessPhoneNumberID uint   `json:"businessPhoneNumberId"`
+		PhoneNumber           string `json:"phoneNumber"`
+		ContactName           string `json:"contactName"`
+		Message               string `json:"message"`
+		// Region overwrites the default region used to parse local phone numbers
+		Region string `json:"region"`
 	}{}
 	err := c.BodyParser(&request)
 	if err != nil {
@@ -38,28 +46,47 @@ func Create(c *fiber.Ctx) error {
 		return errors.New("missing message")
 	}
 
-	parsedPhoneNumber, err := phonenumber.Parse(request.PhoneNumber, true)
+	parsedPhoneNumber, err := phonenumber.ParseInRegion(request.PhoneNumber, request.Region, true)
 	if err != nil {
 		return err
 	}
 
+	businessNumber := &models.BusinessPhoneNumber{}
+	if request.BusinessPhoneNumberID == 0 {
+		businessNumber, err = models.DefaultBusinessPhoneNumber()
+	} else {
+		err = DB.First(businessNumber, request.BusinessPhoneNumberID).Error
+	}
+	if err != nil {
+		return errors.New("unknown business phone number")
+	}
+
 	message := models.Message{
-		WhatsappID: parsedPhoneNumber.WhatsappMessageID,
+		WhatsappID: wamid.New(parsedPhoneNumber.WaID, wamid.Inbound),
 		Direction:  models.DirectionOut,
 		Message:    request.Message,
 		Timestamp:  time.Now().Unix(),
 	}
-	err = message.CreateOrAppend(parsedPhoneNumber.Parsed)
+	err = message.CreateOrAppend(businessNumber.ID, parsedPhoneNumber.Parsed, parsedPhoneNumber.WaID)
 	if err != nil {
 		return err
 	}
 
+	if request.ContactName != "" {
+		err = DB.Model(&models.Conversation{}).Where("id = ?", message.ConversationID).Update("contact_name", request.ContactName).Error
+		if err != nil {
+			return err
+		}
+	}
+
 	conversationResp := models.Conversation{}
 	err = DB.Model(&models.Conversation{}).Preload("Messages.Buttons").First(&conversationResp, message.ConversationID).Error
 	if err != nil {
 		return err
 	}
 
+	conversationResp.SetPhoneNumberInfo()
+
 	webhook.NotivyMessage(message, false)
 
 	return c.JSON(conversationResp)
@@ -79,6 +106,39 @@ func getConversationFromParam(c *fiber.Ctx) (*models.Conversation, error) {
 	return conversation, err
 }
 
+func UpdateContact(c *fiber.Ctx) error {
+	request := struct {
+		ContactName     string  `json:"contactName"`
+		WaID            *string `json:"waId"`
+		HideProfileName bool    `json:"hideProfileName"`
+	}{}
+	err := c.BodyParser(&request)
+	if err != nil {
+		return err
+	}
+
+	conversation, err := getConversationFromParam(c)
+	if err != nil {
+		return err
+	}
+
+	if request.WaID != nil && *request.WaID == "" {
+		request.WaID = nil
+	}
+
+	conversation.ContactName = request.ContactName
+	conversation.WaID = request.WaID
+	conversation.HideProfileName = request.HideProfileName
+
+	err = DB.Model(&models.Conversation{}).Where("id = ?", conversation.ID).Select("ContactName", "WaID", "HideProfileName").Updates(conversation).Error
+	if err != nil {
+		return err
+	}
+	conversation.SetPhoneNumberInfo()
+
+	return c.JSON(conversation)
+}
+
 func CreateMessage(c *fiber.Ctx) error {
 	request := struct {
 		Message string
@@ -96,23 +156,34 @@ func CreateMessage(c *fiber.Ctx) error {
 		return err
 	}
 
+	newMessage, err := SendContactMessage(conversation, request.Message, nil)
+	if err != nil {
+		return err
+	}
+
+	conversation.Messages = append(conversation.Messages, newMessage)
+
+	return c.JSON(conversation)
+}
+
+// SendContactMessage sends a message from the simulated user to the business, payload is the payload of the clicked button
+func SendContactMessage(conversation *models.Conversation, text string, payload *string) (models.Message, error) {
 	newMessage := models.Message{
 		ConversationID: conversation.ID,
-		WhatsappID:     phonenumber.CreateWhatsappID(conversation.PhoneNumber),
+		WhatsappID:     wamid.New(conversation.ContactWaID(), wamid.Inbound),
 		Direction:      models.DirectionOut,
-		Message:        request.Message,
+		Message:        text,
 		Timestamp:      time.Now().Unix(),
+		Payload:        payload,
 	}
-	err = DB.Create(&newMessage).Error
+	err := DB.Create(&newMessage).Error
 	if err != nil {
-		return err
+		return newMessage, err
 	}
 
-	conversation.Messages = append(conversation.Messages, newMessage)
-
 	webhook.NotivyMessage(newMessage, false)
 
-	return c.JSON(conversation)
+	return newMessage, nil
 }
 
 func BtnQuickReply(c *fiber.Ctx) error {
@@ -134,22 +205,12 @@ func BtnQuickReply(c *fiber.Ctx) error {
 		return errors.New("button does not belong to conversation")
 	}
 
-	newMessage := models.Message{
-		ConversationID: conversation.ID,
-		WhatsappID:     phonenumber.CreateWhatsappID(conversation.PhoneNumber),
-		Direction:      models.DirectionOut,
-		Message:        button.Text,
-		Timestamp:      time.Now().Unix(),
-		Payload:        button.Payload,
-	}
-	err = DB.Create(&newMessage).Error
+	newMessage, err := SendContactMessage(conversation, button.Text, button.Payload)
 	if err != nil {
 		return err
 	}
 
 	conversation.Messages = append(conversation.Messages, newMessage)
 
-	webhook.NotivyMessage(newMessage, false)
-
 	return c.JSON(conversation)
 }
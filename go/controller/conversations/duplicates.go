@@ -0,0 +1,25 @@
+package conversations
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/mjarkk/whatsapp-dev/go/models"
+)
+
+// Duplicates lists the suspected duplicate business messages, optionally of a single conversation (?conversationId=).
+// The window defaults to the window of the duplicate settings and can be overwritten using ?windowSeconds=.
+func Duplicates(c *fiber.Ctx) error {
+	window := models.Duplicates.Get().Window()
+	windowSeconds := c.QueryInt("windowSeconds")
+	if windowSeconds > 0 {
+		window = time.Duration(windowSeconds) * time.Second
+	}
+
+	duplicates, err := models.SuspectedDuplicates(uint(c.QueryInt("conversationId")), window)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(duplicates)
+}
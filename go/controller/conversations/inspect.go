@@ -0,0 +1,29 @@
+package conversations
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	. "github.com/mjarkk/whatsapp-dev/go/db"
+	"github.com/mjarkk/whatsapp-dev/go/models"
+)
+
+// InspectMessage returns the stored send message request and response of a business message
+func InspectMessage(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return err
+	}
+
+	message := models.Message{}
+	err = DB.First(&message, id).Error
+	if err != nil {
+		return err
+	}
+
+	inspection := message.Inspection()
+	if inspection == nil {
+		return errors.New("message has no stored send request, only messages sent using the graph api can be inspected. Rejected sends create no message, see /api/traffic for those")
+	}
+	return c.JSON(inspection)
+}
@@ -0,0 +1,31 @@
+package conversations
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/mjarkk/whatsapp-dev/go/lib/bots"
+	"github.com/mjarkk/whatsapp-dev/go/lib/expectations"
+	"github.com/mjarkk/whatsapp-dev/go/lib/faults"
+	"github.com/mjarkk/whatsapp-dev/go/lib/ratelimit"
+	"github.com/mjarkk/whatsapp-dev/go/lib/traffic"
+	"github.com/mjarkk/whatsapp-dev/go/lib/webhook"
+	"github.com/mjarkk/whatsapp-dev/go/models"
+)
+
+// Reset removes all conversations, faults, expectations, recorded traffic and rate limit counters,
+// cancels the pending bot responses and webhooks and stops the requests waiting for a message.
+// Other settings like templates, tokens and bots are kept.
+func Reset(c *fiber.Ctx) error {
+	err := models.DeleteAllConversations()
+	if err != nil {
+		return err
+	}
+	faults.Clear()
+	expectations.Clear()
+	traffic.Clear()
+	bots.CancelPlanned()
+	webhook.CancelPending()
+	ratelimit.Reset()
+	models.ConversationsReset.Notify()
+
+	return c.JSON(map[string]bool{"success": true})
+}
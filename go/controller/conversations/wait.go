@@ -0,0 +1,98 @@
+package conversations
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	. "github.com/mjarkk/whatsapp-dev/go/db"
+	"github.com/mjarkk/whatsapp-dev/go/models"
+	"github.com/mjarkk/whatsapp-dev/go/utils/phonenumber"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultWaitTimeout = 30 * time.Second
+	maxWaitTimeout     = 5 * time.Minute
+	// waitRecheckInterval is the interval at which the database is checked even if no new message was announced
+	waitRecheckInterval = time.Second
+)
+
+// WaitForMessage waits for the first business message matching the query and returns it together with a cursor.
+// Query parameters (all optional):
+//   - conversation: id of the conversation
+//   - to: phone number of the recipient
+//   - contains: text the message must contain
+//   - template: name of the template the message was sent with
+//   - since: only match messages after this cursor, use the cursor of the previous response to wait for the next message
+//   - timeout: how long to wait, for example 10s (default 30s, max 5m)
+//
+// A reset while waiting stops the wait with status 409.
+func WaitForMessage(c *fiber.Ctx) error {
+	timeout := defaultWaitTimeout
+	if c.Query("timeout") != "" {
+		var err error
+		timeout, err = time.ParseDuration(c.Query("timeout"))
+		if err != nil {
+			return errors.New("invalid timeout, expected a duration like 30s")
+		}
+		if timeout <= 0 || timeout > maxWaitTimeout {
+			return errors.New("timeout must be between 0s and 5m")
+		}
+	}
+
+	query := DB.Model(&models.Message{}).Where("direction = ? AND id > ?", models.DirectionIn, c.QueryInt("since"))
+	if c.Query("conversation") != "" {
+		query = query.Where("conversation_id = ?", c.QueryInt("conversation"))
+	}
+	if c.Query("to") != "" {
+		to, err := phonenumber.Parse(c.Query("to"), true)
+		if err != nil {
+			return errors.New("invalid to: " + err.Error())
+		}
+		conversations := DB.Model(&models.Conversation{}).Select("id").Where("phone_number = ? OR phone_number = ? OR wa_id = ?", to.Parsed, to.WaID, to.WaID)
+		query = query.Where("conversation_id IN (?)", conversations)
+	}
+	if c.Query("contains") != "" {
+		query = query.Where("instr(message, ?) > 0", c.Query("contains"))
+	}
+	if c.Query("template") != "" {
+		query = query.Where("template_name = ?", c.Query("template"))
+	}
+
+	deadline := time.After(timeout)
+	reset := models.ConversationsReset.Wait()
+	for {
+		// Start listening before querying so messages created in between are not missed
+		created := models.MessageCreated.Wait()
+
+		message := models.Message{}
+		err := query.Session(&gorm.Session{}).Preload("Buttons").Order("id").First(&message).Error
+		if err == nil {
+			return c.JSON(struct {
+				Message models.Message `json:"message"`
+				// Cursor can be used as since parameter to wait for the next message
+				Cursor uint `json:"cursor"`
+			}{
+				Message: message,
+				Cursor:  message.ID,
+			})
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		select {
+		case <-created:
+		case <-time.After(waitRecheckInterval):
+		case <-reset:
+			return c.Status(fiber.StatusConflict).JSON(map[string]string{
+				"error": "conversations were reset while waiting for a message",
+			})
+		case <-deadline:
+			return c.Status(fiber.StatusRequestTimeout).JSON(map[string]string{
+				"error": "no matching message within " + timeout.String(),
+			})
+		}
+	}
+}
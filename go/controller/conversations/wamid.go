@@ -0,0 +1,50 @@
+package conversations
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	. "github.com/mjarkk/whatsapp-dev/go/db"
+	"github.com/mjarkk/whatsapp-dev/go/models"
+	"github.com/mjarkk/whatsapp-dev/go/utils/wamid"
+	"gorm.io/gorm"
+)
+
+// DecodeWamid decodes the whatsapp message id of ?id= and returns the message it refers to, if it exists
+func DecodeWamid(c *fiber.Ctx) error {
+	id := c.Query("id")
+	if id == "" {
+		return errors.New("missing id")
+	}
+
+	decoded, err := wamid.Decode(id)
+	if err != nil {
+		return err
+	}
+
+	var message *models.Message
+	var conversation *models.Conversation
+	found := models.Message{}
+	err = DB.Where("whatsapp_id = ?", id).Preload("Buttons").First(&found).Error
+	if err == nil {
+		message = &found
+		conversation = &models.Conversation{}
+		err = DB.First(conversation, found.ConversationID).Error
+		if err != nil {
+			return err
+		}
+		conversation.SetPhoneNumberInfo()
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	return c.JSON(struct {
+		wamid.ID
+		Message      *models.Message      `json:"message"`
+		Conversation *models.Conversation `json:"conversation"`
+	}{
+		ID:           decoded,
+		Message:      message,
+		Conversation: conversation,
+	})
+}
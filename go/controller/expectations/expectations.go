@@ -0,0 +1,49 @@
+package expectations
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/mjarkk/whatsapp-dev/go/lib/expectations"
+)
+
+// Index returns all expectations with their status and diffs
+func Index(c *fiber.Ctx) error {
+	results, err := expectations.Evaluate()
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(results)
+}
+
+// Create validates and registers an expectation, only messages sent after this call can satisfy it
+func Create(c *fiber.Ctx) error {
+	expectation := expectations.Expectation{}
+	err := c.BodyParser(&expectation)
+	if err != nil {
+		return err
+	}
+
+	expectation, err = expectations.Add(expectation)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(expectation)
+}
+
+// Delete removes the expectation with the id, unknown ids are ignored
+func Delete(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return err
+	}
+
+	expectations.Remove(uint(id))
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// Clear removes all expectations
+func Clear(c *fiber.Ctx) error {
+	expectations.Clear()
+	return c.SendStatus(fiber.StatusNoContent)
+}
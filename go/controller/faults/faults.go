@@ -0,0 +1,35 @@
+package faults
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/mjarkk/whatsapp-dev/go/lib/faults"
+)
+
+func Index(c *fiber.Ctx) error {
+	return c.JSON(faults.List())
+}
+
+func Create(c *fiber.Ctx) error {
+	fault := faults.Fault{}
+	err := c.BodyParser(&fault)
+	if err != nil {
+		return err
+	}
+
+	fault, err = faults.Add(fault)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fault)
+}
+
+func Delete(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return err
+	}
+
+	faults.Remove(uint(id))
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,57 @@
+package messages
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	. "github.com/mjarkk/whatsapp-dev/go/db"
+	"github.com/mjarkk/whatsapp-dev/go/models"
+	"github.com/mjarkk/whatsapp-dev/go/utils/phonenumber"
+)
+
+const redacted = "REDACTED"
+
+// sendMessageResponse writes the response of a successful send message request and stores it with the message
+func sendMessageResponse(c *fiber.Ctx, to *phonenumber.ParsedPhoneNumber, message *models.Message) error {
+	err := sendResponse(c, to, message.WhatsappID)
+	if err != nil {
+		return err
+	}
+
+	message.RawResponse = string(c.Response().Body())
+	return DB.Model(&models.Message{}).Where("id = ?", message.ID).Update("raw_response", message.RawResponse).Error
+}
+
+// redactedHeaders returns the request headers with the access token redacted
+func redactedHeaders(c *fiber.Ctx) map[string]string {
+	headers := map[string]string{}
+	c.Request().Header.VisitAll(func(key, value []byte) {
+		name := string(key)
+		if strings.EqualFold(name, fiber.HeaderAuthorization) {
+			scheme, _, found := strings.Cut(string(value), " ")
+			if found {
+				headers[name] = scheme + " " + redacted
+			} else {
+				headers[name] = redacted
+			}
+			return
+		}
+		headers[name] = string(value)
+	})
+	return headers
+}
+
+// redactedURL returns the request url with the access_token query parameter redacted
+func redactedURL(c *fiber.Ctx) string {
+	requestURL, err := url.Parse(c.OriginalURL())
+	if err != nil {
+		return c.Path()
+	}
+	query := requestURL.Query()
+	if query.Has("access_token") {
+		query.Set("access_token", redacted)
+		requestURL.RawQuery = query.Encode()
+	}
+	return requestURL.String()
+}
@@ -2,133 +2,46 @@ package messages
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/mjarkk/whatsapp-dev/go/controller/bots"
 	"github.com/mjarkk/whatsapp-dev/go/controller/websocket"
 	. "github.com/mjarkk/whatsapp-dev/go/db"
+	"github.com/mjarkk/whatsapp-dev/go/lib/faults"
+	"github.com/mjarkk/whatsapp-dev/go/lib/graph"
+	"github.com/mjarkk/whatsapp-dev/go/lib/ratelimit"
+	"github.com/mjarkk/whatsapp-dev/go/lib/webhook"
 	"github.com/mjarkk/whatsapp-dev/go/models"
-	"github.com/mjarkk/whatsapp-dev/go/state"
 	"github.com/mjarkk/whatsapp-dev/go/utils/phonenumber"
+	"github.com/mjarkk/whatsapp-dev/go/utils/wamid"
 )
 
-func parseVersion(c *fiber.Ctx) error {
-	versionParam := c.Params("version")
-	versionParts := strings.Split(versionParam, ".")
-	majorVersion, err := strconv.Atoi(versionParts[0])
-	if err != nil {
-		return err
-	}
-	if majorVersion <= 10 {
-		return errors.New("incorrect facebook grapth api version, only major version higher than 10 are supported")
-	}
-	return nil
-}
-
-type ErrorKind uint8
-
-const (
-	AuthTokenMalformed ErrorKind = iota
-	AuthTokenInvalidAuthKind
-	AuthTokenMissingAuthKind
-	AuthTokenCannotBeDecrypted
-	AuthTokenInvalidContentType
-	RecipientPhoneNumberNotAllowed
-)
-
-func ErrValues(kind ErrorKind) (status int, code int, message string) {
-	switch kind {
-	case AuthTokenMalformed:
-		return 400, 190, "Malformed access token"
-	case AuthTokenInvalidAuthKind:
-		return 401, 190, "Invalid auth type in access token"
-	case AuthTokenMissingAuthKind:
-		return 400, 190, "Missing authentication header"
-	case AuthTokenCannotBeDecrypted:
-		return 401, 190, "The access token could not be decrypted"
-	case AuthTokenInvalidContentType:
-		return 400, 190, "Invalid content type (application/json)"
-	case RecipientPhoneNumberNotAllowed:
-		return 400, 131030, "(#131030) Recipient phone number not in allowed list"
-	default:
-		return 400, 102, "Unknown error kind"
+func Create(c *fiber.Ctx) error {
+	contentType := strings.ToLower(c.Get(fiber.HeaderContentType))
+	if contentType != "application/json" {
+		return graph.KindError(graph.AuthTokenInvalidContentType)
 	}
-}
-
-func authError(c *fiber.Ctx, kind ErrorKind) error {
-	httpStatusCode, code, message := ErrValues(kind)
-
-	c.Response().Header.Set("www-authenticate", `OAuth "Facebook Platform" "invalid_request" "`+message+`"`)
-
-	return c.Status(httpStatusCode).JSON(map[string]any{
-		"error": map[string]any{
-			"message":    message,
-			"type":       "OAuthException",
-			"code":       code,
-			"fbtrace_id": "MDAwMDAwMDAwMDAwMDAwMDAw",
-		}})
-}
-
-func customError(c *fiber.Ctx, message string, details ...string) error {
-	c.Response().Header.Set("www-authenticate", `OAuth "Facebook Platform" "invalid_request" "`+message+`"`)
 
-	errData := map[string]any{
-		"message":    message,
-		"type":       "OAuthException",
-		"code":       100,
-		"fbtrace_id": "MDAwMDAwMDAwMDAwMDAwMDAw",
+	phoneNumberID := c.Params("phoneNumberId")
+	businessNumber, err := models.FindBusinessPhoneNumber(phoneNumberID)
+	if err != nil {
+		return graph.UnknownObjectError("post", phoneNumberID)
 	}
-
-	if len(details) > 0 {
-		errData["error_data"] = map[string]any{
-			"messaging_product": "whatsapp",
-			"details":           details[0],
-		}
+	if businessNumber.RegistrationStatus != models.RegistrationStatusRegistered {
+		return graph.KnownError(133010, "Phone number "+businessNumber.DisplayPhoneNumber()+" is not registered.")
 	}
 
-	return c.Status(400).JSON(map[string]any{"error": errData})
-}
-
-func Create(c *fiber.Ctx) error {
-	err := parseVersion(c)
+	businessAccount, err := businessNumber.BusinessAccount()
 	if err != nil {
 		return err
 	}
-
-	c.Response().Header.Set("facebook-api-version", "v18.0")
-
-	headers := c.GetReqHeaders()
-	authHeader := ""
-	hasContentType := false
-	for key, value := range headers {
-		switch strings.ToLower(key) {
-		case "content-type":
-			if strings.ToLower(value) != "application/json" {
-				return authError(c, AuthTokenInvalidContentType)
-			}
-			hasContentType = true
-		case "authorization":
-			authHeader = value
-		}
-	}
-	if !hasContentType {
-		return authError(c, AuthTokenInvalidContentType)
-	}
-
-	// Validate auth token
-	if authHeader == "" {
-		return authError(c, AuthTokenMissingAuthKind)
-	}
-	_, after, found := strings.Cut(authHeader, "Bearer ")
-	if !found {
-		return authError(c, AuthTokenInvalidAuthKind)
-	}
-	if state.GraphToken.Get() != after {
-		return authError(c, AuthTokenMalformed)
+	err = ratelimit.CheckBusinessUseCase(c, businessAccount.WabaID)
+	if err != nil {
+		return err
 	}
 
 	// Validate request content
@@ -143,81 +56,151 @@ func Create(c *fiber.Ctx) error {
 	}{}
 	err = json.Unmarshal(bodyBytes, &body)
 	if err != nil {
-		return customError(c, "(#100) The parameter messaging_product is required.", "Invalid JSON, err: "+err.Error())
+		return graph.CustomError("(#100) The parameter messaging_product is required.", "Invalid JSON, err: "+err.Error())
 	}
 	if body.To == "" {
-		return customError(c, "The parameter to is required.")
+		return graph.CustomError("The parameter to is required.")
 	}
 	if strings.ToLower(body.MessagingProduct) != "whatsapp" {
 		messagingProductJSON, _ := json.Marshal(body.MessagingProduct)
 		messagingProductJSONStr := string(messagingProductJSON)
 		errMsg := fmt.Sprintf("(#100) Param messaging_product must be one of {WHATSAPP} - got %s.", messagingProductJSONStr)
-		return customError(c, errMsg)
+		return graph.CustomError(errMsg)
 	}
 
 	to, err := phonenumber.Parse(body.To, false)
 	if err != nil {
-		return authError(c, RecipientPhoneNumberNotAllowed)
+		return graph.KnownError(131009, "Parameter 'to' is invalid: "+err.Error())
+	}
+	if !businessNumber.RecipientAllowed(to.Parsed) {
+		return graph.KnownError(131030)
+	}
+
+	messageType := strings.ToLower(body.Type)
+	if messageType == "" {
+		messageType = "text"
+	}
+	fault := faults.Message{Recipient: to.Parsed, Type: messageType}
+	if body.Template != nil {
+		fault.TemplateName = body.Template.Name
+	}
+	err = faults.Apply(fault)
+	if err != nil {
+		return err
 	}
 
-	switch strings.ToLower(body.Type) {
-	case "", "text":
+	err = ratelimit.CheckThroughput(businessNumber.PhoneNumberID)
+	if err != nil {
+		return err
+	}
+	err = ratelimit.CheckPair(businessNumber.PhoneNumberID, to.Parsed)
+	if err != nil {
+		return err
+	}
+
+	// Rejected requests, here or in the handlers of the message types, create no message and thus cannot be inspected, they are only recorded in the traffic
+	send := sendOptions{
+		payloadHash:    models.PayloadHash(businessNumber.PhoneNumberID, to.Parsed, bodyBytes),
+		rawRequest:     string(bodyBytes),
+		requestHeaders: redactedHeaders(c),
+		requestURL:     redactedURL(c),
+		graphVersion:   graph.CalledVersion(c).String(),
+	}
+	duplicates := models.Duplicates.Get()
+	if duplicates.Mode == models.DuplicateModeFlag || duplicates.Mode == models.DuplicateModeDedupe {
+		original, err := models.FindRecentDuplicate(send.payloadHash, duplicates.Window())
+		if err != nil {
+			return err
+		}
+		if original != nil {
+			if duplicates.Mode == models.DuplicateModeDedupe {
+				// Deduplicated sends create no message, the inspection of the original message shows the first request
+				return sendResponse(c, to, original.WhatsappID)
+			}
+			send.duplicateOfID = &original.ID
+		}
+	}
+
+	switch messageType {
+	case "text":
 		if body.Text == nil {
-			return customError(c, "(#100) Invalid parameter", "Parameter 'text' is mandatory for type 'text'")
+			return graph.CustomError("(#100) Invalid parameter", "Parameter 'text' is mandatory for type 'text'")
 		}
-		return handleSendTextMessage(c, *body.Text, to)
+		return handleSendTextMessage(c, *body.Text, to, businessNumber, send)
 	case "template":
 		if body.Template == nil {
-			return customError(c, "(#100) Invalid parameter", "Parameter 'template' is mandatory for type 'template'")
+			return graph.CustomError("(#100) Invalid parameter", "Parameter 'template' is mandatory for type 'template'")
 		}
-		return handleSendTemplateMessage(c, *body.Template, to)
+		return handleSendTemplateMessage(c, *body.Template, to, businessNumber, send)
 	default:
-		return customError(c, "(#100) Invalid parameter", "Parameter 'type' must be one of {TEXT, TEMPLATE}")
+		return graph.CustomError("(#100) Invalid parameter", "Parameter 'type' must be one of {TEXT, TEMPLATE}")
 	}
 }
 
+// sendOptions contains the details of a send message request that are stored with the message
+type sendOptions struct {
+	payloadHash    string
+	duplicateOfID  *uint
+	rawRequest     string
+	requestHeaders map[string]string
+	requestURL     string
+	graphVersion   string
+}
+
+// sendResponse writes the response of a successful send message request
+func sendResponse(c *fiber.Ctx, to *phonenumber.ParsedPhoneNumber, whatsappID string) error {
+	message := map[string]string{"id": whatsappID, "message_status": "accepted"}
+
+	return c.JSON(map[string]any{
+		"messaging_product": "whatsapp",
+		"contacts": []map[string]string{{
+			"input": to.Original,
+			"wa_id": to.WaID,
+		}},
+		"messages": []map[string]string{message},
+	})
+}
+
 type TextOptions struct {
 	Body string `json:"body"`
 }
 
-func handleSendTextMessage(c *fiber.Ctx, text TextOptions, to *phonenumber.ParsedPhoneNumber) error {
+func handleSendTextMessage(c *fiber.Ctx, text TextOptions, to *phonenumber.ParsedPhoneNumber, from *models.BusinessPhoneNumber, send sendOptions) error {
 	if text.Body == "" {
-		return customError(c, "(#100) The parameter text['body'] is required.")
+		return graph.CustomError("(#100) The parameter text['body'] is required.")
 	}
-
-	conversation := models.Conversation{}
-	err := DB.Model(&models.Conversation{}).First(&conversation, "phone_number = ?", to.Parsed).Error
-	if err != nil {
-		return authError(c, RecipientPhoneNumberNotAllowed)
+	if from.ConversationRequired() {
+		// Only templates can start a conversation
+		_, err := models.FindConversation(from.ID, to.Parsed, to.WaID)
+		if err != nil {
+			return graph.KnownError(131030)
+		}
 	}
 
 	message := &models.Message{
-		ConversationID: uint(conversation.ID),
-		WhatsappID:     to.WhatsappMessageID,
+		WhatsappID:     wamid.New(to.WaID, wamid.Outbound),
 		Direction:      models.DirectionIn,
 		Message:        text.Body,
 		Timestamp:      time.Now().Unix(),
+		PayloadHash:    send.payloadHash,
+		DuplicateOfID:  send.duplicateOfID,
+		RawRequest:     send.rawRequest,
+		RequestHeaders: send.requestHeaders,
+		RequestURL:     send.requestURL,
+		GraphVersion:   send.graphVersion,
 	}
-	err = DB.Create(message).Error
+	err := message.CreateOrAppend(from.ID, to.Parsed, to.WaID)
 	if err != nil {
-		return customError(c, "(#100) WhatsApp-Dev Error creating message", err.Error())
+		return graph.CustomError("(#100) WhatsApp-Dev Error creating message", err.Error())
 	}
 
 	websocket.SendMessage(*message)
+	bots.OnBusinessMessage(*message)
 
-	// FIXME notify webhook
+	webhook.NotivyStatus(*message, webhook.StatusSent)
+	webhook.NotivyStatus(*message, webhook.StatusDelivered)
 
-	return c.JSON(map[string]any{
-		"messaging_product": "whatsapp",
-		"contacts": []map[string]string{{
-			"input": to.Original,
-			"wa_id": to.Parsed,
-		}},
-		"messages": []map[string]string{{
-			"id":             message.WhatsappID,
-			"message_status": "accepted",
-		}},
-	})
+	return sendMessageResponse(c, to, message)
 }
 
 type TemplateOptions struct {
@@ -240,24 +223,23 @@ type TemplateComponent struct {
 	} `json:"parameters"`
 }
 
-func handleSendTemplateMessage(c *fiber.Ctx, template TemplateOptions, to *phonenumber.ParsedPhoneNumber) error {
+func handleSendTemplateMessage(c *fiber.Ctx, template TemplateOptions, to *phonenumber.ParsedPhoneNumber, from *models.BusinessPhoneNumber, send sendOptions) error {
 	if template.Language.Code == "" {
-		return customError(c, "(#100) The parameter template['language']['code'] is required.")
+		return graph.CustomError("(#100) The parameter template['language']['code'] is required.")
 	}
 	switch strings.ToLower(template.Language.Policy) {
 	case "", "deterministic":
 		// In case the policy is not set or the value is uppercased
 		template.Language.Policy = "deterministic"
 	default:
-		return customError(c, "(#100) The parameter template['language']['policy'] must be one of {DETERMINISTIC}.")
+		return graph.CustomError("(#100) The parameter template['language']['policy'] must be one of {DETERMINISTIC}.")
 	}
 
 	msgTemplate := models.Template{}
-	err := DB.Model(&models.Template{}).Where("name = ?", template.Name).Preload("TemplateCustomButtons").First(&msgTemplate).Error
+	err := DB.Model(&models.Template{}).Where("business_account_id = ? AND name = ?", from.BusinessAccountID, template.Name).Preload("TemplateCustomButtons").First(&msgTemplate).Error
 	if err != nil {
-		msg := "(#132001) Template name does not exist in the translation"
 		details := fmt.Sprintf("template name (%s) does not exist in %s", template.Name, template.Language.Code)
-		return customError(c, msg, details)
+		return graph.KnownError(132001, details)
 	}
 
 	var requestBodyVariables []string
@@ -269,26 +251,26 @@ func handleSendTemplateMessage(c *fiber.Ctx, template TemplateOptions, to *phone
 			buttons = append(buttons, component)
 		case "body":
 			if requestBodyVariables != nil {
-				return customError(c, "There can be at max 1 body component")
+				return graph.CustomError("There can be at max 1 body component")
 			}
 			for j, parameter := range component.Parameters {
 				if strings.ToLower(parameter.Type) == "text" {
 					requestBodyVariables = append(requestBodyVariables, parameter.Text)
 				} else {
 					msg := fmt.Sprintf("Param template['components'][%d]['parameters'][%d]['type'] must be one of {TEXT}", idx, j)
-					return customError(c, msg)
+					return graph.CustomError(msg)
 				}
 			}
 		case "header":
 			if requestHeaderVariables != nil {
-				return customError(c, "There can be at max 1 header component")
+				return graph.CustomError("There can be at max 1 header component")
 			}
 			for j, parameter := range component.Parameters {
 				if strings.ToLower(parameter.Type) == "text" {
 					requestHeaderVariables = append(requestHeaderVariables, parameter.Text)
 				} else {
 					msg := fmt.Sprintf("Param template['components'][%d]['parameters'][%d]['type'] must be one of {TEXT}", idx, j)
-					return customError(c, msg)
+					return graph.CustomError(msg)
 				}
 			}
 		}
@@ -301,13 +283,12 @@ func handleSendTemplateMessage(c *fiber.Ctx, template TemplateOptions, to *phone
 	templateBodyVariables := models.Variables(body)
 	if len(templateBodyVariables) > 0 {
 		if len(requestBodyVariables) != len(templateBodyVariables) {
-			msg := "(#132000) Number of parameters does not match the expected number of params"
 			detials := fmt.Sprintf(
 				"body: number of localizable_params (%d) does not match the expected number of params (%d)",
 				len(requestBodyVariables),
 				len(templateBodyVariables),
 			)
-			return customError(c, msg, detials)
+			return graph.KnownError(132000, detials)
 		}
 
 		body = models.ReplaceVariables(body, requestBodyVariables)
@@ -317,13 +298,12 @@ func handleSendTemplateMessage(c *fiber.Ctx, template TemplateOptions, to *phone
 		templateHeaderVariables := models.Variables(*msgTemplate.Header)
 		if len(templateHeaderVariables) > 0 {
 			if len(requestHeaderVariables) != len(templateHeaderVariables) {
-				msg := "(#132000) Number of parameters does not match the expected number of params"
 				detials := fmt.Sprintf(
 					"header: number of localizable_params (%d) does not match the expected number of params (%d)",
 					len(requestHeaderVariables),
 					len(templateHeaderVariables),
 				)
-				return customError(c, msg, detials)
+				return graph.KnownError(132000, detials)
 			}
 
 			newHeader := models.ReplaceVariables(*header, requestHeaderVariables)
@@ -332,13 +312,12 @@ func handleSendTemplateMessage(c *fiber.Ctx, template TemplateOptions, to *phone
 	}
 
 	if len(msgTemplate.TemplateCustomButtons) != len(buttons) {
-		msg := "(#132000) Number of parameters does not match the expected number of params"
 		details := fmt.Sprintf(
 			"number of buttons (%d) does not match the expected number of params (%d)",
 			len(buttons),
 			len(msgTemplate.TemplateCustomButtons),
 		)
-		return customError(c, msg, details)
+		return graph.KnownError(132000, details)
 	}
 
 	messageButtons := []models.MessageButton{}
@@ -354,40 +333,40 @@ func handleSendTemplateMessage(c *fiber.Ctx, template TemplateOptions, to *phone
 			prefix := fmt.Sprintf("template['components'][%d]", idx)
 
 			if button.Index == "" {
-				return customError(c, fmt.Sprintf("Param %s['index'] is required", prefix))
+				return graph.CustomError(fmt.Sprintf("Param %s['index'] is required", prefix))
 			}
 			if button.SubType == "" {
-				return customError(c, fmt.Sprintf("Param %s['sub_type'] is required", prefix))
+				return graph.CustomError(fmt.Sprintf("Param %s['sub_type'] is required", prefix))
 			}
 			if button.SubType != "quick_reply" {
-				return customError(c, fmt.Sprintf("Param %s['sub_type'] must be one of {QUICK_REPLY}", prefix))
+				return graph.CustomError(fmt.Sprintf("Param %s['sub_type'] must be one of {QUICK_REPLY}", prefix))
 			}
 
 			switch len(button.Parameters) {
 			case 0:
-				return customError(c, fmt.Sprintf("Param %s['parameters'] is required", prefix))
+				return graph.CustomError(fmt.Sprintf("Param %s['parameters'] is required", prefix))
 			case 1:
 				// continue
 			default:
-				return customError(c, fmt.Sprintf("Param %s['parameters'] must have at max 1 element", prefix))
+				return graph.CustomError(fmt.Sprintf("Param %s['parameters'] must have at max 1 element", prefix))
 			}
 			firstParam := button.Parameters[0]
 			if firstParam.Type == "" {
-				return customError(c, fmt.Sprintf("Param %s['parameters'][0]['type'] is required", prefix))
+				return graph.CustomError(fmt.Sprintf("Param %s['parameters'][0]['type'] is required", prefix))
 			}
 			if firstParam.Type != "payload" {
-				return customError(c, fmt.Sprintf("Param %s['parameters'][0]['type'] must be one of {PAYLOAD}", prefix))
+				return graph.CustomError(fmt.Sprintf("Param %s['parameters'][0]['type'] must be one of {PAYLOAD}", prefix))
 			}
 			if firstParam.Payload == "" {
-				return customError(c, fmt.Sprintf("Param %s['parameters'][0]['payload'] is required", prefix))
+				return graph.CustomError(fmt.Sprintf("Param %s['parameters'][0]['payload'] is required", prefix))
 			}
 
 			buttonIndex, err := strconv.Atoi(button.Index)
 			if err != nil {
-				return customError(c, fmt.Sprintf("Param %s['index'] must be a number", prefix))
+				return graph.CustomError(fmt.Sprintf("Param %s['index'] must be a number", prefix))
 			}
 			if buttonIndex < 0 || buttonIndex >= len(buttonsPayload) {
-				return customError(c, fmt.Sprintf("Param %s['index'] must be between 0 and %d", prefix, len(buttonsPayload)-1))
+				return graph.CustomError(fmt.Sprintf("Param %s['index'] must be between 0 and %d", prefix, len(buttonsPayload)-1))
 			}
 
 			buttonsPayload[buttonIndex] = ButtonPayload{
@@ -399,7 +378,7 @@ func handleSendTemplateMessage(c *fiber.Ctx, template TemplateOptions, to *phone
 
 		for idx, btn := range buttonsPayload {
 			if !btn.Seen {
-				return customError(c, fmt.Sprintf("Button with index %d missing", idx))
+				return graph.CustomError(fmt.Sprintf("Button with index %d missing", idx))
 			}
 			messageButtons = append(messageButtons, models.MessageButton{
 				Text:    msgTemplate.TemplateCustomButtons[idx].Text,
@@ -410,33 +389,31 @@ func handleSendTemplateMessage(c *fiber.Ctx, template TemplateOptions, to *phone
 	}
 
 	message := &models.Message{
-		WhatsappID:    to.WhatsappMessageID,
-		Direction:     models.DirectionIn,
-		HeaderMessage: header,
-		Message:       body,
-		FooterMessage: footer,
-		Timestamp:     time.Now().Unix(),
-		Buttons:       messageButtons,
-	}
-	// Note that templates can be send to everyone
-	err = message.CreateOrAppend(to.Parsed)
+		WhatsappID:     wamid.New(to.WaID, wamid.Outbound),
+		Direction:      models.DirectionIn,
+		HeaderMessage:  header,
+		Message:        body,
+		FooterMessage:  footer,
+		Timestamp:      time.Now().Unix(),
+		Buttons:        messageButtons,
+		PayloadHash:    send.payloadHash,
+		DuplicateOfID:  send.duplicateOfID,
+		RawRequest:     send.rawRequest,
+		RequestHeaders: send.requestHeaders,
+		RequestURL:     send.requestURL,
+		GraphVersion:   send.graphVersion,
+		TemplateName:   &msgTemplate.Name,
+	}
+	err = message.CreateOrAppend(from.ID, to.Parsed, to.WaID)
 	if err != nil {
 		return err
 	}
 
 	websocket.SendMessage(*message)
+	bots.OnBusinessMessage(*message)
 
-	// FIXME notify webhook
+	webhook.NotivyStatus(*message, webhook.StatusSent)
+	webhook.NotivyStatus(*message, webhook.StatusDelivered)
 
-	return c.JSON(map[string]any{
-		"messaging_product": "whatsapp",
-		"contacts": []map[string]string{{
-			"input": to.Original,
-			"wa_id": to.Parsed,
-		}},
-		"messages": []map[string]string{{
-			"id":             message.WhatsappID,
-			"message_status": "accepted",
-		}},
-	})
+	return sendMessageResponse(c, to, message)
 }
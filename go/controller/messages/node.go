@@ -0,0 +1,79 @@
+package messages
+
+import (
+	"strconv"
+
+	. "github.com/mjarkk/whatsapp-dev/go/db"
+	"github.com/mjarkk/whatsapp-dev/go/lib/graph"
+	"github.com/mjarkk/whatsapp-dev/go/models"
+)
+
+// FindMessage returns the message with the whatsapp message id (wamid)
+func FindMessage(whatsappID string) (*models.Message, error) {
+	message := &models.Message{}
+	err := DB.Where("whatsapp_id = ?", whatsappID).Preload("Buttons").First(message).Error
+	return message, err
+}
+
+// MessageNode returns the message as graph api node for GET /{wamid}
+func MessageNode(message *models.Message) (graph.Node, error) {
+	conversation := models.Conversation{}
+	err := DB.First(&conversation, message.ConversationID).Error
+	if err != nil {
+		return graph.Node{}, err
+	}
+	businessNumber := models.BusinessPhoneNumber{}
+	err = DB.First(&businessNumber, conversation.BusinessPhoneNumberID).Error
+	if err != nil {
+		return graph.Node{}, err
+	}
+
+	// Messages with direction in are sent by the business to the simulated user
+	from := businessNumber.PhoneNumber
+	to := conversation.ContactWaID()
+	if message.Direction == models.DirectionOut {
+		from, to = to, from
+	}
+
+	buttons := []map[string]any{}
+	for _, button := range message.Buttons {
+		buttons = append(buttons, map[string]any{
+			"text":    button.Text,
+			"payload": button.Payload,
+		})
+	}
+
+	var header, footer any
+	if message.HeaderMessage != nil {
+		header = *message.HeaderMessage
+	}
+	if message.FooterMessage != nil {
+		footer = *message.FooterMessage
+	}
+
+	return graph.Node{
+		Type: "WhatsAppBusinessMessage",
+		Fields: map[string]any{
+			"id":                message.WhatsappID,
+			"messaging_product": "whatsapp",
+			"from":              from,
+			"to":                to,
+			"timestamp":         strconv.FormatInt(message.Timestamp, 10),
+			"text":              map[string]string{"body": message.Message},
+			"header":            header,
+			"footer":            footer,
+			"buttons":           buttons,
+			"conversation": graph.Node{
+				Type: "WhatsAppConversation",
+				Fields: map[string]any{
+					"id":           strconv.Itoa(int(conversation.ID)),
+					"wa_id":        conversation.ContactWaID(),
+					"phone_number": conversation.PhoneNumber,
+					"contact_name": conversation.ContactProfileName(),
+				},
+				Defaults: []string{"id", "wa_id", "phone_number", "contact_name"},
+			},
+		},
+		Defaults: []string{"id", "messaging_product", "from", "to", "timestamp", "text"},
+	}, nil
+}
@@ -0,0 +1,81 @@
+package nodes
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/mjarkk/whatsapp-dev/go/controller/messages"
+	"github.com/mjarkk/whatsapp-dev/go/controller/phonenumbers"
+	"github.com/mjarkk/whatsapp-dev/go/controller/templates"
+	"github.com/mjarkk/whatsapp-dev/go/controller/uploads"
+	. "github.com/mjarkk/whatsapp-dev/go/db"
+	"github.com/mjarkk/whatsapp-dev/go/lib/graph"
+	"github.com/mjarkk/whatsapp-dev/go/models"
+	"github.com/mjarkk/whatsapp-dev/go/utils/wamid"
+)
+
+// findNode returns the node with the id and the scope required to read it
+func findNode(c *fiber.Ctx, id string) (*graph.Node, string, error) {
+	if strings.HasPrefix(id, wamid.Prefix) {
+		message, err := messages.FindMessage(id)
+		if err != nil {
+			return nil, "", nil
+		}
+		node, err := messages.MessageNode(message)
+		return &node, models.ScopeBusinessMessaging, err
+	}
+
+	number, err := models.FindBusinessPhoneNumber(id)
+	if err == nil {
+		node, err := phonenumbers.PhoneNumberNode(c, number)
+		return &node, models.ScopeBusinessManagement, err
+	}
+
+	media, err := uploads.FindMedia(id)
+	if err == nil {
+		node := uploads.MediaNode(c, media)
+		return &node, models.ScopeBusinessMessaging, nil
+	}
+
+	templateID, err := strconv.Atoi(id)
+	if err == nil {
+		template := models.Template{}
+		err = DB.Preload("TemplateCustomButtons").First(&template, templateID).Error
+		if err == nil {
+			node := templates.TemplateNode(template)
+			return &node, models.ScopeBusinessManagement, nil
+		}
+	}
+
+	return nil, "", nil
+}
+
+// Get returns the selected fields of an object like GET /{id}.
+// Supported objects are phone numbers, templates, media and messages (wamid).
+func Get(c *fiber.Ctx) error {
+	id, err := url.PathUnescape(c.Params("id"))
+	if err != nil {
+		id = c.Params("id")
+	}
+
+	node, scope, err := findNode(c, id)
+	if err != nil {
+		return err
+	}
+	if node == nil {
+		return graph.UnknownObjectError("get", id)
+	}
+
+	err = graph.CheckScope(c, scope)
+	if err != nil {
+		return err
+	}
+
+	data, err := graph.SelectFields(c, *node)
+	if err != nil {
+		return err
+	}
+	return c.JSON(data)
+}
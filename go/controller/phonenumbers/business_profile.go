@@ -0,0 +1,182 @@
+package phonenumbers
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	. "github.com/mjarkk/whatsapp-dev/go/db"
+	"github.com/mjarkk/whatsapp-dev/go/lib/graph"
+	"github.com/mjarkk/whatsapp-dev/go/models"
+	"gorm.io/gorm"
+)
+
+func getBusinessProfile(number *models.BusinessPhoneNumber) (*models.BusinessProfile, error) {
+	profile := &models.BusinessProfile{}
+	err := DB.Where("business_phone_number_id = ?", number.ID).First(profile).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &models.BusinessProfile{
+			BusinessPhoneNumberID: number.ID,
+			Websites:              []string{},
+		}, nil
+	}
+	return profile, err
+}
+
+// BusinessProfileNode returns the business profile of the phone number as graph api node
+func BusinessProfileNode(c *fiber.Ctx, number *models.BusinessPhoneNumber) (graph.Node, error) {
+	profile, err := getBusinessProfile(number)
+	if err != nil {
+		return graph.Node{}, err
+	}
+
+	node := graph.Node{
+		Type: "WhatsAppBusinessProfile",
+		Fields: map[string]any{
+			"about":               profile.About,
+			"address":             profile.Address,
+			"description":         profile.Description,
+			"email":               profile.Email,
+			"websites":            profile.Websites,
+			"vertical":            profile.Vertical,
+			"profile_picture_url": nil,
+			"messaging_product":   "whatsapp",
+		},
+		Defaults: []string{"about", "address", "description", "email", "websites", "vertical", "profile_picture_url", "messaging_product"},
+	}
+	if profile.ProfilePictureUploadID != nil {
+		upload := models.Upload{}
+		err = DB.First(&upload, *profile.ProfilePictureUploadID).Error
+		if err == nil {
+			node.Fields["profile_picture_url"] = c.BaseURL() + "/profile_pictures/" + upload.SessionID
+		}
+	}
+
+	return node, nil
+}
+
+// GetBusinessProfile returns the business profile like GET /{phone-number-id}/whatsapp_business_profile
+func GetBusinessProfile(c *fiber.Ctx) error {
+	number, err := getPhoneNumber(c, "get")
+	if err != nil {
+		return err
+	}
+
+	node, err := BusinessProfileNode(c, number)
+	if err != nil {
+		return err
+	}
+	profileData, err := graph.SelectFields(c, node)
+	if err != nil {
+		return err
+	}
+	profileData["messaging_product"] = "whatsapp"
+
+	return c.JSON(map[string]any{
+		"data": []map[string]any{profileData},
+	})
+}
+
+// UpdateBusinessProfile updates the business profile like POST /{phone-number-id}/whatsapp_business_profile
+func UpdateBusinessProfile(c *fiber.Ctx) error {
+	number, err := getPhoneNumber(c, "post")
+	if err != nil {
+		return err
+	}
+
+	body := struct {
+		MessagingProduct     string    `json:"messaging_product"`
+		About                *string   `json:"about"`
+		Address              *string   `json:"address"`
+		Description          *string   `json:"description"`
+		Email                *string   `json:"email"`
+		Websites             *[]string `json:"websites"`
+		Vertical             *string   `json:"vertical"`
+		ProfilePictureHandle *string   `json:"profile_picture_handle"`
+	}{}
+	err = parseBody(c, &body)
+	if err != nil {
+		return err
+	}
+	if strings.ToLower(body.MessagingProduct) != "whatsapp" {
+		return graph.CustomError("(#100) The parameter messaging_product is required.")
+	}
+
+	profile, err := getBusinessProfile(number)
+	if err != nil {
+		return err
+	}
+
+	textFields := []struct {
+		name   string
+		value  *string
+		target *string
+		min    int
+		max    int
+	}{
+		{"about", body.About, &profile.About, 1, 139},
+		{"address", body.Address, &profile.Address, 0, 256},
+		{"description", body.Description, &profile.Description, 0, 512},
+		{"email", body.Email, &profile.Email, 0, 128},
+	}
+	for _, field := range textFields {
+		if field.value == nil {
+			continue
+		}
+		length := len([]rune(*field.value))
+		if length < field.min || length > field.max {
+			return graph.CustomError(fmt.Sprintf("(#100) Param %s must be between %d and %d characters long.", field.name, field.min, field.max))
+		}
+		*field.target = *field.value
+	}
+
+	if body.Websites != nil {
+		websites := *body.Websites
+		if len(websites) > 2 {
+			return graph.CustomError("(#100) Param websites must have at most 2 elements.")
+		}
+		for idx, website := range websites {
+			if !strings.HasPrefix(website, "http://") && !strings.HasPrefix(website, "https://") {
+				return graph.CustomError(fmt.Sprintf("(#100) Param websites[%d] must be a valid URL starting with http:// or https://", idx))
+			}
+			if len(website) > 256 {
+				return graph.CustomError(fmt.Sprintf("(#100) Param websites[%d] must be at most 256 characters long.", idx))
+			}
+		}
+		profile.Websites = websites
+	}
+
+	if body.Vertical != nil {
+		validVertical := false
+		for _, vertical := range models.BusinessVerticals {
+			if vertical == *body.Vertical {
+				validVertical = true
+				break
+			}
+		}
+		if !validVertical {
+			return graph.CustomError(fmt.Sprintf("(#100) Param vertical must be one of {%s}", strings.Join(models.BusinessVerticals, ", ")))
+		}
+		profile.Vertical = *body.Vertical
+	}
+
+	if body.ProfilePictureHandle != nil {
+		upload := models.Upload{}
+		err = DB.Where("handle = ?", *body.ProfilePictureHandle).First(&upload).Error
+		if err != nil {
+			return graph.KnownError(131009, "profile_picture_handle is not a valid upload handle")
+		}
+		if !strings.HasPrefix(upload.FileType, "image/") {
+			return graph.KnownError(131009, "profile_picture_handle must be an image")
+		}
+		profile.ProfilePictureUploadID = &upload.ID
+	}
+
+	err = DB.Save(profile).Error
+	if err != nil {
+		return err
+	}
+
+	return success(c)
+}
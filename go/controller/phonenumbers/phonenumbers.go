@@ -0,0 +1,257 @@
+package phonenumbers
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	. "github.com/mjarkk/whatsapp-dev/go/db"
+	"github.com/mjarkk/whatsapp-dev/go/lib/graph"
+	"github.com/mjarkk/whatsapp-dev/go/models"
+	"github.com/mjarkk/whatsapp-dev/go/utils/random"
+)
+
+var pinRegex = regexp.MustCompile(`^\d{6}$`)
+
+func getPhoneNumber(c *fiber.Ctx, method string) (*models.BusinessPhoneNumber, error) {
+	phoneNumberID := c.Params("phoneNumberId")
+	number, err := models.FindBusinessPhoneNumber(phoneNumberID)
+	if err != nil {
+		return nil, graph.UnknownObjectError(method, phoneNumberID)
+	}
+
+	return number, nil
+}
+
+func parseBody(c *fiber.Ctx, body any) error {
+	err := json.Unmarshal(c.Body(), body)
+	if err != nil {
+		return graph.CustomError("(#100) Invalid parameter", "Invalid JSON, err: "+err.Error())
+	}
+	return nil
+}
+
+func success(c *fiber.Ctx) error {
+	return c.JSON(map[string]bool{"success": true})
+}
+
+// PhoneNumberNode returns the phone number as graph api node for GET /{phone-number-id}
+func PhoneNumberNode(c *fiber.Ctx, number *models.BusinessPhoneNumber) (graph.Node, error) {
+	platformType := "NOT_APPLICABLE"
+	status := "PENDING"
+	if number.RegistrationStatus == models.RegistrationStatusRegistered {
+		platformType = "CLOUD_API"
+		status = "CONNECTED"
+	}
+
+	profile, err := BusinessProfileNode(c, number)
+	if err != nil {
+		return graph.Node{}, err
+	}
+
+	node := graph.Node{
+		Type: "WhatsAppBusinessPhoneNumber",
+		Fields: map[string]any{
+			"verified_name":                number.VerifiedName,
+			"code_verification_status":     number.CodeVerificationStatus,
+			"display_phone_number":         number.DisplayPhoneNumber(),
+			"quality_rating":               number.QualityRating,
+			"platform_type":                platformType,
+			"throughput":                   map[string]string{"level": "STANDARD"},
+			"id":                           number.PhoneNumberID,
+			"name_status":                  "APPROVED",
+			"status":                       status,
+			"account_mode":                 "LIVE",
+			"messaging_limit_tier":         "TIER_1K",
+			"is_official_business_account": false,
+			"whatsapp_business_profile":    profile,
+		},
+		Defaults: []string{"verified_name", "code_verification_status", "display_phone_number", "quality_rating", "platform_type", "throughput", "id"},
+	}
+	if graph.SupportsField(c, node.Type, "health_status") {
+		canSendMessage := "AVAILABLE"
+		if number.RegistrationStatus != models.RegistrationStatusRegistered {
+			canSendMessage = "BLOCKED"
+		}
+		node.Fields["health_status"] = map[string]any{
+			"can_send_message": canSendMessage,
+			"entities": []map[string]string{{
+				"entity_type":      "PHONE_NUMBER",
+				"id":               number.PhoneNumberID,
+				"can_send_message": canSendMessage,
+			}},
+		}
+	}
+
+	return node, nil
+}
+
+// SetTwoStepPin sets the two-step verification pin like POST /{phone-number-id}
+func SetTwoStepPin(c *fiber.Ctx) error {
+	number, err := getPhoneNumber(c, "post")
+	if err != nil {
+		return err
+	}
+
+	body := struct {
+		Pin string `json:"pin"`
+	}{}
+	err = parseBody(c, &body)
+	if err != nil {
+		return err
+	}
+	if body.Pin == "" {
+		return graph.CustomError("(#100) The parameter pin is required.")
+	}
+	if !pinRegex.MatchString(body.Pin) {
+		return graph.CustomError("(#100) Param pin must be 6 characters long.")
+	}
+
+	number.TwoStepPin = &body.Pin
+	err = DB.Save(number).Error
+	if err != nil {
+		return err
+	}
+
+	return success(c)
+}
+
+// Register registers the phone number for use with the cloud api
+func Register(c *fiber.Ctx) error {
+	number, err := getPhoneNumber(c, "post")
+	if err != nil {
+		return err
+	}
+
+	body := struct {
+		MessagingProduct string `json:"messaging_product"`
+		Pin              string `json:"pin"`
+	}{}
+	err = parseBody(c, &body)
+	if err != nil {
+		return err
+	}
+	if strings.ToLower(body.MessagingProduct) != "whatsapp" {
+		return graph.CustomError("(#100) The parameter messaging_product is required.")
+	}
+	if body.Pin == "" {
+		return graph.CustomError("(#100) The parameter pin is required.")
+	}
+	if !pinRegex.MatchString(body.Pin) {
+		return graph.CustomError("(#100) Param pin must be 6 characters long.")
+	}
+
+	if number.CodeVerificationStatus != models.CodeVerificationStatusVerified {
+		return graph.KnownError(133006)
+	}
+	if number.TwoStepPin != nil && *number.TwoStepPin != body.Pin {
+		return graph.KnownError(133005)
+	}
+
+	// Registering a number without a two-step verification pin sets the pin
+	number.TwoStepPin = &body.Pin
+	number.RegistrationStatus = models.RegistrationStatusRegistered
+	err = DB.Save(number).Error
+	if err != nil {
+		return err
+	}
+
+	return success(c)
+}
+
+// Deregister deregisters the phone number, after this the number can no longer send messages
+func Deregister(c *fiber.Ctx) error {
+	number, err := getPhoneNumber(c, "post")
+	if err != nil {
+		return err
+	}
+
+	number.RegistrationStatus = models.RegistrationStatusUnregistered
+	err = DB.Save(number).Error
+	if err != nil {
+		return err
+	}
+
+	return success(c)
+}
+
+// RequestCode "sends" a verification code to the phone number.
+// As there is no real phone, the code is logged and shown in the UI.
+func RequestCode(c *fiber.Ctx) error {
+	number, err := getPhoneNumber(c, "post")
+	if err != nil {
+		return err
+	}
+
+	body := struct {
+		CodeMethod string `json:"code_method"`
+		Language   string `json:"language"`
+	}{}
+	err = parseBody(c, &body)
+	if err != nil {
+		return err
+	}
+	switch strings.ToUpper(body.CodeMethod) {
+	case "SMS", "VOICE":
+		// Valid code method
+	case "":
+		return graph.CustomError("(#100) The parameter code_method is required.")
+	default:
+		return graph.CustomError("(#100) Param code_method must be one of {SMS, VOICE}")
+	}
+	if body.Language == "" {
+		return graph.CustomError("(#100) The parameter language is required.")
+	}
+
+	randomSource := rand.New(rand.NewSource(time.Now().UnixNano()))
+	code := random.Numbers(randomSource, 6)
+
+	number.VerificationCode = &code
+	if number.CodeVerificationStatus == models.CodeVerificationStatusVerified {
+		number.CodeVerificationStatus = models.CodeVerificationStatusExpired
+	}
+	err = DB.Save(number).Error
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Verification code for %s (%s): %s\n", number.DisplayPhoneNumber(), strings.ToUpper(body.CodeMethod), code)
+
+	return success(c)
+}
+
+// VerifyCode verifies the code send by RequestCode
+func VerifyCode(c *fiber.Ctx) error {
+	number, err := getPhoneNumber(c, "post")
+	if err != nil {
+		return err
+	}
+
+	body := struct {
+		Code string `json:"code"`
+	}{}
+	err = parseBody(c, &body)
+	if err != nil {
+		return err
+	}
+	if body.Code == "" {
+		return graph.CustomError("(#100) The parameter code is required.")
+	}
+
+	if number.VerificationCode == nil || *number.VerificationCode != strings.ReplaceAll(body.Code, "-", "") {
+		return graph.KnownError(136025)
+	}
+
+	number.VerificationCode = nil
+	number.CodeVerificationStatus = models.CodeVerificationStatusVerified
+	err = DB.Save(number).Error
+	if err != nil {
+		return err
+	}
+
+	return success(c)
+}
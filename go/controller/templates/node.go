@@ -0,0 +1,60 @@
+package templates
+
+import (
+	"strconv"
+
+	"github.com/mjarkk/whatsapp-dev/go/lib/graph"
+	"github.com/mjarkk/whatsapp-dev/go/models"
+)
+
+// TemplateNode converts a template into the graph api node of GET /{template-id}.
+// Templates have no language or category, they are shown as en_US and MARKETING.
+func TemplateNode(template models.Template) graph.Node {
+	components := []map[string]any{}
+	if template.Header != nil {
+		components = append(components, map[string]any{
+			"type":   "HEADER",
+			"format": "TEXT",
+			"text":   *template.Header,
+		})
+	}
+	components = append(components, map[string]any{
+		"type": "BODY",
+		"text": template.Body,
+	})
+	if template.Footer != nil {
+		components = append(components, map[string]any{
+			"type": "FOOTER",
+			"text": *template.Footer,
+		})
+	}
+	if len(template.TemplateCustomButtons) > 0 {
+		buttons := []map[string]any{}
+		for _, button := range template.TemplateCustomButtons {
+			buttons = append(buttons, map[string]any{
+				"type": "QUICK_REPLY",
+				"text": button.Text,
+			})
+		}
+		components = append(components, map[string]any{
+			"type":    "BUTTONS",
+			"buttons": buttons,
+		})
+	}
+
+	return graph.Node{
+		Type: "WhatsAppMessageTemplate",
+		Fields: map[string]any{
+			"id":               strconv.Itoa(int(template.ID)),
+			"name":             template.Name,
+			"language":         "en_US",
+			"status":           "APPROVED",
+			"category":         "MARKETING",
+			"components":       components,
+			"parameter_format": "POSITIONAL",
+			"quality_score":    map[string]any{"score": "UNKNOWN", "date": template.CreatedAt.Unix()},
+			"rejected_reason":  "NONE",
+		},
+		Defaults: []string{"id", "name", "language", "status", "category", "components"},
+	}
+}
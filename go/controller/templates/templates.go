@@ -10,8 +10,14 @@ import (
 )
 
 func Index(c *fiber.Ctx) error {
+	query := DB.Model(&models.Template{}).Preload("TemplateCustomButtons")
+	businessAccountID := c.QueryInt("businessAccountId")
+	if businessAccountID > 0 {
+		query = query.Where("business_account_id = ?", businessAccountID)
+	}
+
 	templates := []models.Template{}
-	err := DB.Model(&models.Template{}).Preload("TemplateCustomButtons").Find(&templates).Error
+	err := query.Find(&templates).Error
 	if err != nil {
 		return err
 	}
@@ -33,11 +39,20 @@ func Create(c *fiber.Ctx) error {
 		return errors.New("body is required")
 	}
 
+	if request.BusinessAccountID == 0 {
+		defaultPhoneNumber, err := models.DefaultBusinessPhoneNumber()
+		if err != nil {
+			return err
+		}
+		request.BusinessAccountID = defaultPhoneNumber.BusinessAccountID
+	}
+
 	template := models.Template{
-		Name:   request.Name,
-		Header: request.Header,
-		Body:   request.Body,
-		Footer: request.Footer,
+		BusinessAccountID: request.BusinessAccountID,
+		Name:              request.Name,
+		Header:            request.Header,
+		Body:              request.Body,
+		Footer:            request.Footer,
 	}
 	template.Validate()
 
@@ -0,0 +1,82 @@
+package tokens
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	. "github.com/mjarkk/whatsapp-dev/go/db"
+	"github.com/mjarkk/whatsapp-dev/go/lib/graph"
+	"github.com/mjarkk/whatsapp-dev/go/models"
+	"github.com/mjarkk/whatsapp-dev/go/state"
+)
+
+// authenticateDebugRequest allows both app access tokens ({app-id}|{app-secret}) and regular access tokens
+func authenticateDebugRequest(c *fiber.Ctx) error {
+	appID, appSecret, isAppToken := strings.Cut(c.Query("access_token"), "|")
+	if isAppToken && appID == state.AppID.Get() && appSecret == state.AppSecret.Get() {
+		return nil
+	}
+
+	return graph.Authenticate(c)
+}
+
+// Debug returns information about an access token like GET /debug_token
+func Debug(c *fiber.Ctx) error {
+	err := authenticateDebugRequest(c)
+	if err != nil {
+		return err
+	}
+
+	inputToken := c.Query("input_token")
+	if inputToken == "" {
+		return graph.CustomError("(#100) The parameter input_token is required")
+	}
+
+	token, err := models.FindAccessToken(inputToken)
+	if err != nil {
+		return graph.CodeError(190, "Invalid OAuth access token - Cannot parse access token")
+	}
+
+	wabaIDs := []string{}
+	err = DB.Model(&models.BusinessAccount{}).Pluck("waba_id", &wabaIDs).Error
+	if err != nil {
+		return err
+	}
+
+	expiresAt := int64(0)
+	if token.ExpiresAt != nil {
+		expiresAt = token.ExpiresAt.Unix()
+	}
+
+	granularScopes := []map[string]any{}
+	for _, scope := range token.Scopes {
+		granularScopes = append(granularScopes, map[string]any{
+			"scope":      scope,
+			"target_ids": wabaIDs,
+		})
+	}
+
+	data := map[string]any{
+		"app_id":                 state.AppID.Get(),
+		"type":                   token.Type,
+		"application":            "WhatsApp Dev",
+		"data_access_expires_at": 0,
+		"expires_at":             expiresAt,
+		"is_valid":               token.IsValid(),
+		"issued_at":              token.CreatedAt.Unix(),
+		"scopes":                 token.Scopes,
+		"granular_scopes":        granularScopes,
+		"user_id":                token.ID,
+	}
+
+	tokenErr := graph.TokenError(token)
+	if tokenErr != nil {
+		data["error"] = map[string]any{
+			"code":    tokenErr.Code,
+			"message": tokenErr.Message,
+			"subcode": tokenErr.Subcode,
+		}
+	}
+
+	return c.JSON(map[string]any{"data": data})
+}
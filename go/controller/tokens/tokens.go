@@ -0,0 +1,146 @@
+package tokens
+
+import (
+	"errors"
+	"math/rand"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	. "github.com/mjarkk/whatsapp-dev/go/db"
+	"github.com/mjarkk/whatsapp-dev/go/models"
+	"github.com/mjarkk/whatsapp-dev/go/utils/random"
+)
+
+func Index(c *fiber.Ctx) error {
+	tokens := []models.AccessToken{}
+	err := DB.Model(&models.AccessToken{}).Find(&tokens).Error
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(tokens)
+}
+
+func Create(c *fiber.Ctx) error {
+	request := struct {
+		Name   string                 `json:"name"`
+		Type   models.AccessTokenType `json:"type"`
+		Scopes []string               `json:"scopes"`
+		// ExpiresIn is the number of seconds the token is valid, 0 means the token never expires
+		ExpiresIn int64 `json:"expiresIn"`
+	}{}
+	err := c.BodyParser(&request)
+	if err != nil {
+		return err
+	}
+
+	if request.Name == "" {
+		return errors.New("name is required")
+	}
+	if request.Name == models.DefaultAccessTokenName {
+		return errors.New("the name " + models.DefaultAccessTokenName + " is reserved for the graph token option")
+	}
+	switch request.Type {
+	case "":
+		request.Type = models.AccessTokenTypeSystemUser
+	case models.AccessTokenTypeUser, models.AccessTokenTypeSystemUser:
+		// Valid type
+	default:
+		return errors.New("type must be one of USER or SYSTEM_USER")
+	}
+	if request.Scopes == nil {
+		request.Scopes = models.AllScopes
+	}
+	err = models.ValidateScopes(request.Scopes)
+	if err != nil {
+		return err
+	}
+	if request.ExpiresIn < 0 {
+		return errors.New("expiresIn cannot be negative")
+	}
+
+	randomSource := rand.New(rand.NewSource(time.Now().UnixNano()))
+	token := models.AccessToken{
+		Name:   request.Name,
+		Token:  "EAA" + random.Alphanumeric(randomSource, 180),
+		Type:   request.Type,
+		Scopes: request.Scopes,
+	}
+	if request.ExpiresIn > 0 {
+		expiresAt := time.Now().Add(time.Duration(request.ExpiresIn) * time.Second)
+		token.ExpiresAt = &expiresAt
+	}
+
+	err = DB.Create(&token).Error
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(token)
+}
+
+func getTokenFromParam(c *fiber.Ctx) (*models.AccessToken, error) {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return nil, err
+	}
+	if id < 1 {
+		return nil, errors.New("invalid id")
+	}
+
+	token := &models.AccessToken{}
+	err = DB.First(token, id).Error
+	return token, err
+}
+
+func Revoke(c *fiber.Ctx) error {
+	request := struct {
+		Reason models.RevokeReason `json:"reason"`
+	}{}
+	err := c.BodyParser(&request)
+	if err != nil {
+		return err
+	}
+
+	switch request.Reason {
+	case "":
+		request.Reason = models.RevokeReasonInvalidated
+	case models.RevokeReasonInvalidated, models.RevokeReasonPasswordChanged, models.RevokeReasonAppRemoved:
+		// Valid reason
+	default:
+		return errors.New("reason must be one of invalidated, password_changed or app_removed")
+	}
+
+	token, err := getTokenFromParam(c)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	token.RevokedAt = &now
+	token.RevokeReason = &request.Reason
+	err = DB.Save(token).Error
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(token)
+}
+
+// Delete permanently deletes a token, the token defined by the graph token option cannot be deleted
+func Delete(c *fiber.Ctx) error {
+	token, err := getTokenFromParam(c)
+	if err != nil {
+		return err
+	}
+	if token.Name == models.DefaultAccessTokenName {
+		return errors.New("the " + models.DefaultAccessTokenName + " token is defined by the graph token option and cannot be deleted, revoke it instead")
+	}
+
+	err = DB.Unscoped().Delete(token).Error
+	if err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,25 @@
+package traffic
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/mjarkk/whatsapp-dev/go/lib/traffic"
+)
+
+// Index returns the recorded graph api and webhook traffic, oldest first.
+// Access tokens are redacted unless the query parameter redact is set to false.
+func Index(c *fiber.Ctx) error {
+	return c.JSON(traffic.List(c.QueryBool("redact", true)))
+}
+
+// HAR returns the recorded traffic as HTTP Archive file that can be loaded in the network tab of browser devtools.
+// Access tokens are redacted unless the query parameter redact is set to false.
+func HAR(c *fiber.Ctx) error {
+	c.Attachment("whatsapp-dev.har")
+	return c.JSON(traffic.ExportHAR(c.QueryBool("redact", true)))
+}
+
+// Clear removes all recorded traffic
+func Clear(c *fiber.Ctx) error {
+	traffic.Clear()
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,121 @@
+package uploads
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	. "github.com/mjarkk/whatsapp-dev/go/db"
+	"github.com/mjarkk/whatsapp-dev/go/lib/graph"
+	"github.com/mjarkk/whatsapp-dev/go/models"
+	"github.com/mjarkk/whatsapp-dev/go/utils/random"
+)
+
+var allowedMediaTypes = []string{
+	"audio/aac",
+	"audio/amr",
+	"audio/mp4",
+	"audio/mpeg",
+	"audio/ogg",
+	"text/plain",
+	"application/pdf",
+	"application/vnd.ms-excel",
+	"application/msword",
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	"image/jpeg",
+	"image/png",
+	"image/webp",
+	"video/3gpp",
+	"video/mp4",
+}
+
+// CreateMedia uploads a media file like POST /{phone-number-id}/media
+func CreateMedia(c *fiber.Ctx) error {
+	if strings.ToLower(c.FormValue("messaging_product")) != "whatsapp" {
+		return graph.CustomError("(#100) The parameter messaging_product is required.")
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return graph.CustomError("(#100) The parameter file is required.")
+	}
+	fileType := c.FormValue("type", fileHeader.Header.Get(fiber.HeaderContentType))
+	allowed := false
+	for _, mediaType := range allowedMediaTypes {
+		if mediaType == fileType {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return graph.CustomError("(#100) Param type must be one of {"+strings.Join(allowedMediaTypes, ", ")+"}", "Unsupported media type "+fileType)
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return err
+	}
+
+	randomSource := rand.New(rand.NewSource(time.Now().UnixNano()))
+	mediaID := random.Numbers(randomSource, 16)
+	upload := models.Upload{
+		SessionID:  random.Hex(randomSource, 16),
+		FileName:   fileHeader.Filename,
+		FileType:   fileType,
+		FileLength: int64(len(data)),
+		Data:       data,
+		MediaID:    &mediaID,
+	}
+	err = DB.Create(&upload).Error
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(map[string]string{"id": mediaID})
+}
+
+// FindMedia returns the uploaded media with the media id
+func FindMedia(mediaID string) (*models.Upload, error) {
+	upload := &models.Upload{}
+	err := DB.Where("media_id = ?", mediaID).First(upload).Error
+	return upload, err
+}
+
+// MediaNode returns the media as graph api node for GET /{media-id}
+func MediaNode(c *fiber.Ctx, upload *models.Upload) graph.Node {
+	hash := sha256.Sum256(upload.Data)
+
+	return graph.Node{
+		Type: "WhatsAppBusinessMedia",
+		Fields: map[string]any{
+			"messaging_product": "whatsapp",
+			"url":               c.BaseURL() + "/media/" + *upload.MediaID,
+			"mime_type":         upload.FileType,
+			"sha256":            hex.EncodeToString(hash[:]),
+			"file_size":         upload.FileLength,
+			"id":                *upload.MediaID,
+		},
+		Defaults: []string{"messaging_product", "url", "mime_type", "sha256", "file_size", "id"},
+	}
+}
+
+// MediaFile serves the media file of a media url
+func MediaFile(c *fiber.Ctx) error {
+	upload, err := FindMedia(c.Params("mediaId"))
+	if err != nil {
+		return fiber.ErrNotFound
+	}
+
+	c.Set(fiber.HeaderContentType, upload.FileType)
+	return c.Send(upload.Data)
+}
@@ -0,0 +1,131 @@
+package uploads
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	. "github.com/mjarkk/whatsapp-dev/go/db"
+	"github.com/mjarkk/whatsapp-dev/go/lib/graph"
+	"github.com/mjarkk/whatsapp-dev/go/models"
+	"github.com/mjarkk/whatsapp-dev/go/utils/random"
+)
+
+var allowedFileTypes = map[string]struct{}{
+	"application/pdf": {},
+	"image/jpeg":      {},
+	"image/jpg":       {},
+	"image/png":       {},
+	"video/mp4":       {},
+}
+
+// CreateSession starts a resumable upload session like POST /{app-id}/uploads
+func CreateSession(c *fiber.Ctx) error {
+	fileLength := c.QueryInt("file_length")
+	if fileLength <= 0 {
+		return graph.CustomError("(#100) The parameter file_length is required.")
+	}
+	fileType := c.Query("file_type")
+	if fileType == "" {
+		return graph.CustomError("(#100) The parameter file_type is required.")
+	}
+	if _, ok := allowedFileTypes[fileType]; !ok {
+		return graph.CustomError("(#100) Param file_type must be one of {application/pdf, image/jpeg, image/jpg, image/png, video/mp4}")
+	}
+
+	randomSource := rand.New(rand.NewSource(time.Now().UnixNano()))
+	upload := models.Upload{
+		SessionID:  random.Hex(randomSource, 16),
+		FileName:   c.Query("file_name"),
+		FileType:   fileType,
+		FileLength: int64(fileLength),
+	}
+	err := DB.Create(&upload).Error
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(map[string]string{"id": "upload:" + upload.SessionID})
+}
+
+func getUpload(c *fiber.Ctx, method string) (*models.Upload, error) {
+	sessionID := c.Params("sessionId")
+	upload := &models.Upload{}
+	err := DB.Where("session_id = ?", sessionID).First(upload).Error
+	if err != nil {
+		return nil, graph.UnknownObjectError(method, "upload:"+sessionID)
+	}
+
+	return upload, nil
+}
+
+// Status returns how much of the file is uploaded like GET /upload:{session-id}
+func Status(c *fiber.Ctx) error {
+	upload, err := getUpload(c, "get")
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(map[string]any{
+		"id":          "upload:" + upload.SessionID,
+		"file_offset": len(upload.Data),
+	})
+}
+
+// Upload uploads (a part of) the file like POST /upload:{session-id}
+func Upload(c *fiber.Ctx) error {
+	upload, err := getUpload(c, "post")
+	if err != nil {
+		return err
+	}
+	if upload.Handle != nil {
+		return graph.CustomError("(#100) Invalid parameter", "The upload session is already completed")
+	}
+
+	fileOffset, err := strconv.Atoi(c.Get("file_offset", "0"))
+	if err != nil {
+		return graph.CustomError("(#100) Param file_offset must be a number")
+	}
+	if fileOffset != len(upload.Data) {
+		return graph.CustomError("(#100) Invalid parameter", fmt.Sprintf("file_offset must be %d", len(upload.Data)))
+	}
+
+	upload.Data = append(upload.Data, c.Body()...)
+	if int64(len(upload.Data)) > upload.FileLength {
+		return graph.CustomError("(#100) Invalid parameter", fmt.Sprintf("The uploaded file is larger than the file_length of %d bytes", upload.FileLength))
+	}
+
+	if int64(len(upload.Data)) == upload.FileLength {
+		handle := "4:" + base64.StdEncoding.EncodeToString([]byte(upload.FileName+":"+upload.FileType)) + ":" + upload.SessionID
+		upload.Handle = &handle
+	}
+
+	err = DB.Save(upload).Error
+	if err != nil {
+		return err
+	}
+
+	if upload.Handle == nil {
+		return c.JSON(map[string]any{
+			"id":          "upload:" + upload.SessionID,
+			"file_offset": len(upload.Data),
+		})
+	}
+
+	return c.JSON(map[string]string{"h": *upload.Handle})
+}
+
+// File serves the uploaded file, this is used for the profile picture urls
+func File(c *fiber.Ctx) error {
+	upload := models.Upload{}
+	err := DB.Where("session_id = ? AND handle IS NOT NULL", c.Params("sessionId")).First(&upload).Error
+	if err != nil {
+		return fiber.ErrNotFound
+	}
+
+	c.Set(fiber.HeaderContentType, upload.FileType)
+	return c.Send(upload.Data)
+}
@@ -18,9 +18,9 @@ func registerWebsocketConnection(c *websocket.Conn) {
 func unregisterWebsocketConnection(c *websocket.Conn) bool {
 	websocketConnectionsLock.Lock()
 	defer websocketConnectionsLock.Unlock()
-	for _, websocketConnection := range websocketConnections {
+	for idx, websocketConnection := range websocketConnections {
 		if websocketConnection == c {
-			websocketConnections = websocketConnections[:len(websocketConnections)-1]
+			websocketConnections = append(websocketConnections[:idx], websocketConnections[idx+1:]...)
 			return true
 		}
 	}
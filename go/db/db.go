@@ -3,6 +3,7 @@ package db
 import (
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
 )
 
 var DB *gorm.DB
@@ -14,3 +15,32 @@ func ConnectToDatabase() {
 		panic("failed to connect database")
 	}
 }
+
+// ConnectToMemoryDatabase connects to a new empty in memory database, this is used by test servers
+func ConnectToMemoryDatabase() error {
+	memoryDB, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Every connection to :memory: opens a new database so all queries have to share one connection
+	sqlDB, err := memoryDB.DB()
+	if err != nil {
+		return err
+	}
+	sqlDB.SetMaxOpenConns(1)
+
+	DB = memoryDB
+	return nil
+}
+
+// CloseDatabase closes the database connection
+func CloseDatabase() error {
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
@@ -0,0 +1,240 @@
+package bots
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/mjarkk/whatsapp-dev/go/models"
+	"github.com/mjarkk/whatsapp-dev/go/utils/phonenumber"
+	"gopkg.in/yaml.v3"
+)
+
+// Bot is a simulated user that automatically responds to messages sent by the business
+type Bot struct {
+	ID   uint   `json:"id" yaml:"-"`
+	Name string `json:"name" yaml:"name"`
+	// PhoneNumber limits the bot to the conversation with this contact, empty means the bot responds in every conversation
+	PhoneNumber string `json:"phoneNumber" yaml:"phoneNumber"`
+	// Rules are checked in order, only the first matching rule is executed
+	Rules []Rule `json:"rules" yaml:"rules"`
+}
+
+// Rule executes the action when the business sends a message matching the trigger
+type Rule struct {
+	When Trigger `json:"when" yaml:"when"`
+	Then Action  `json:"then" yaml:"then"`
+}
+
+// Trigger matches a message sent by the business, empty fields match every message
+type Trigger struct {
+	Type     string `json:"type" yaml:"type"` // "text", "template"
+	Template string `json:"template" yaml:"template"`
+	// Contains is text the message must contain
+	Contains string `json:"contains" yaml:"contains"`
+}
+
+// Action is the response of the simulated user, either a reply or a button click
+type Action struct {
+	Reply string `json:"reply" yaml:"reply"`
+	// ClickButton is the index of the button to click, the first button has index 0
+	ClickButton *int `json:"clickButton" yaml:"clickButton"`
+	// ClickButtonText clicks the button with this text
+	ClickButtonText string `json:"clickButtonText" yaml:"clickButtonText"`
+	// DelayMs is the time the simulated user takes to respond
+	DelayMs int `json:"delayMs" yaml:"delayMs"`
+}
+
+// Planned is an action a bot will execute
+type Planned struct {
+	Bot    string
+	Action Action
+	// generation is used to drop actions planned before the bots were cleared or cancelled
+	generation uint
+}
+
+var (
+	lock       sync.Mutex
+	nextID     uint = 1
+	bots            = []Bot{}
+	generation uint
+)
+
+// List returns all bots
+func List() []Bot {
+	lock.Lock()
+	defer lock.Unlock()
+
+	return append([]Bot{}, bots...)
+}
+
+// Add validates and adds a bot
+func Add(bot Bot) (Bot, error) {
+	if bot.PhoneNumber != "" {
+		phoneNumber, err := phonenumber.Parse(bot.PhoneNumber, true)
+		if err != nil {
+			return bot, errors.New("phoneNumber is not a valid phone number")
+		}
+		bot.PhoneNumber = phoneNumber.Parsed
+	}
+	if len(bot.Rules) == 0 {
+		return bot, errors.New("a bot needs at least one rule")
+	}
+	for idx, rule := range bot.Rules {
+		err := validateRule(rule)
+		if err != nil {
+			return bot, fmt.Errorf("rule %d: %s", idx+1, err.Error())
+		}
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	bot.ID = nextID
+	nextID++
+	bots = append(bots, bot)
+	return bot, nil
+}
+
+func validateRule(rule Rule) error {
+	switch rule.When.Type {
+	case "", "text", "template":
+		// Valid type
+	default:
+		return errors.New("type must be one of text or template")
+	}
+	if rule.When.Type == "text" && rule.When.Template != "" {
+		return errors.New("text messages have no template")
+	}
+
+	actions := 0
+	if rule.Then.Reply != "" {
+		actions++
+	}
+	if rule.Then.ClickButton != nil {
+		actions++
+		if *rule.Then.ClickButton < 0 {
+			return errors.New("clickButton cannot be negative")
+		}
+	}
+	if rule.Then.ClickButtonText != "" {
+		actions++
+	}
+	if actions != 1 {
+		return errors.New("then must have exactly one of reply, clickButton or clickButtonText")
+	}
+	if rule.Then.DelayMs < 0 {
+		return errors.New("delayMs cannot be negative")
+	}
+	return nil
+}
+
+// Remove removes the bot with the id
+func Remove(id uint) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	for idx, bot := range bots {
+		if bot.ID == id {
+			bots = append(bots[:idx], bots[idx+1:]...)
+			return
+		}
+	}
+}
+
+// Clear removes all bots and cancels their planned actions
+func Clear() {
+	lock.Lock()
+	defer lock.Unlock()
+
+	bots = []Bot{}
+	generation++
+}
+
+// CancelPlanned cancels all planned actions that are not yet executed, the bots are kept
+func CancelPlanned() {
+	lock.Lock()
+	defer lock.Unlock()
+
+	generation++
+}
+
+// Active returns false if the planned action was cancelled
+func (p Planned) Active() bool {
+	lock.Lock()
+	defer lock.Unlock()
+
+	return p.generation == generation
+}
+
+// LoadFile adds the bots from a YAML (.yaml or .yml) or JSON file containing a list of bots
+func LoadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	list := []Bot{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &list)
+	default:
+		err = json.Unmarshal(data, &list)
+	}
+	if err != nil {
+		return err
+	}
+
+	for idx, bot := range list {
+		_, err = Add(bot)
+		if err != nil {
+			return fmt.Errorf("bot %d: %s", idx+1, err.Error())
+		}
+	}
+	return nil
+}
+
+// Plan returns the actions of the bots responding to a message the business sent in the conversation
+func Plan(message models.Message, conversation models.Conversation) []Planned {
+	lock.Lock()
+	defer lock.Unlock()
+
+	planned := []Planned{}
+	for _, bot := range bots {
+		if bot.PhoneNumber != "" && bot.PhoneNumber != conversation.PhoneNumber && phonenumber.WaID(bot.PhoneNumber) != conversation.ContactWaID() {
+			continue
+		}
+
+		for _, rule := range bot.Rules {
+			if matches(rule.When, message) {
+				planned = append(planned, Planned{Bot: bot.Name, Action: rule.Then, generation: generation})
+				break
+			}
+		}
+	}
+	return planned
+}
+
+func matches(trigger Trigger, message models.Message) bool {
+	messageType := "text"
+	templateName := ""
+	if message.TemplateName != nil {
+		messageType = "template"
+		templateName = *message.TemplateName
+	}
+
+	if trigger.Type != "" && trigger.Type != messageType {
+		return false
+	}
+	if trigger.Template != "" && trigger.Template != templateName {
+		return false
+	}
+	if trigger.Contains != "" && !strings.Contains(message.Message, trigger.Contains) {
+		return false
+	}
+	return true
+}
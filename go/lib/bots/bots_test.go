@@ -0,0 +1,166 @@
+package bots
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/mjarkk/whatsapp-dev/go/models"
+)
+
+func templateMessage(name string, text string) models.Message {
+	return models.Message{TemplateName: &name, Message: text}
+}
+
+func TestMatches(t *testing.T) {
+	tests := []struct {
+		name     string
+		trigger  Trigger
+		message  models.Message
+		expected bool
+	}{
+		{"empty trigger matches text", Trigger{}, models.Message{Message: "Hi"}, true},
+		{"empty trigger matches template", Trigger{}, templateMessage("hello_world", "Hello"), true},
+		{"text type", Trigger{Type: "text"}, models.Message{Message: "Hi"}, true},
+		{"text type does not match template", Trigger{Type: "text"}, templateMessage("hello_world", "Hello"), false},
+		{"template type does not match text", Trigger{Type: "template"}, models.Message{Message: "Hi"}, false},
+		{"template name", Trigger{Template: "hello_world"}, templateMessage("hello_world", "Hello"), true},
+		{"other template name", Trigger{Template: "order_update"}, templateMessage("hello_world", "Hello"), false},
+		{"template name does not match text", Trigger{Template: "hello_world"}, models.Message{Message: "hello_world"}, false},
+		{"contains", Trigger{Contains: "pick a time"}, models.Message{Message: "Please pick a time"}, true},
+		{"contains is case sensitive", Trigger{Contains: "Pick a time"}, models.Message{Message: "Please pick a time"}, false},
+		{"all fields", Trigger{Type: "template", Template: "hello_world", Contains: "Hello"}, templateMessage("hello_world", "Hello there"), true},
+		{"all fields but one", Trigger{Type: "template", Template: "hello_world", Contains: "Bye"}, templateMessage("hello_world", "Hello there"), false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if matches(test.trigger, test.message) != test.expected {
+				t.Fatalf("expected %t", test.expected)
+			}
+		})
+	}
+}
+
+func TestPlan(t *testing.T) {
+	defer Clear()
+
+	reply := func(text string) Action {
+		return Action{Reply: text}
+	}
+
+	_, err := Add(Bot{
+		Name: "everyone",
+		Rules: []Rule{
+			{When: Trigger{Template: "order_confirmation"}, Then: reply("yes")},
+			{When: Trigger{Contains: "order"}, Then: reply("order?")},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = Add(Bot{
+		Name:        "one contact",
+		PhoneNumber: "+31612345678",
+		Rules:       []Rule{{When: Trigger{}, Then: reply("hi")}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	contact := models.Conversation{PhoneNumber: "31612345678"}
+	otherContact := models.Conversation{PhoneNumber: "31687654321"}
+
+	tests := []struct {
+		name         string
+		message      models.Message
+		conversation models.Conversation
+		expected     []string
+	}{
+		{"first matching rule only", templateMessage("order_confirmation", "Your order"), otherContact, []string{"everyone: yes"}},
+		{"second rule", models.Message{Message: "Your order shipped"}, otherContact, []string{"everyone: order?"}},
+		{"no matching rule", models.Message{Message: "Hi"}, otherContact, []string{}},
+		{"bot of the contact", models.Message{Message: "Hi"}, contact, []string{"one contact: hi"}},
+		{"both bots", models.Message{Message: "Your order shipped"}, contact, []string{"everyone: order?", "one contact: hi"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actions := []string{}
+			for _, planned := range Plan(test.message, test.conversation) {
+				actions = append(actions, planned.Bot+": "+planned.Action.Reply)
+				if !planned.Active() {
+					t.Fatal("expected a planned action to be active")
+				}
+			}
+			if !reflect.DeepEqual(actions, test.expected) {
+				t.Fatalf("expected %v, got %v", test.expected, actions)
+			}
+		})
+	}
+
+	planned := Plan(models.Message{Message: "Hi"}, contact)
+	CancelPlanned()
+	if planned[0].Active() {
+		t.Fatal("expected the planned action to be cancelled")
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	defer Clear()
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"bots.yaml": `
+- name: happy customer
+  rules:
+    - when: {template: order_confirmation}
+      then: {reply: "yes", delayMs: 2000}
+    - when: {contains: pick a time}
+      then: {clickButton: 1}
+`,
+		"bots.json": `[{"name": "grumpy customer", "phoneNumber": "+31612345678", "rules": [{"when": {}, "then": {"clickButtonText": "Cancel order"}}]}]`,
+	}
+	for name, content := range files {
+		err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = LoadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("%s: %s", name, err.Error())
+		}
+	}
+
+	list := List()
+	if len(list) != 2 {
+		t.Fatalf("expected 2 bots, got %d", len(list))
+	}
+	byName := map[string]Bot{}
+	for _, bot := range list {
+		byName[bot.Name] = bot
+	}
+
+	happy := byName["happy customer"]
+	if len(happy.Rules) != 2 || happy.Rules[0].When.Template != "order_confirmation" || happy.Rules[0].Then.Reply != "yes" || happy.Rules[0].Then.DelayMs != 2000 {
+		t.Fatalf("unexpected rules of the yaml bot: %+v", happy.Rules)
+	}
+	if happy.Rules[1].Then.ClickButton == nil || *happy.Rules[1].Then.ClickButton != 1 {
+		t.Fatalf("expected the yaml bot to click button 1, got %+v", happy.Rules[1].Then)
+	}
+
+	grumpy := byName["grumpy customer"]
+	if grumpy.PhoneNumber != "31612345678" || grumpy.Rules[0].Then.ClickButtonText != "Cancel order" {
+		t.Fatalf("unexpected json bot: %+v", grumpy)
+	}
+
+	invalid := filepath.Join(dir, "invalid.yml")
+	err := os.WriteFile(invalid, []byte("- name: no rules\n"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if LoadFile(invalid) == nil {
+		t.Fatal("expected a bot without rules to be rejected")
+	}
+}
@@ -0,0 +1,290 @@
+package expectations
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/mjarkk/whatsapp-dev/go/db"
+	"github.com/mjarkk/whatsapp-dev/go/models"
+	"github.com/mjarkk/whatsapp-dev/go/utils/phonenumber"
+)
+
+// Expectation describes a message the business should send, empty fields match every message
+type Expectation struct {
+	ID          uint   `json:"id"`
+	Description string `json:"description"`
+
+	To       string `json:"to"`
+	Type     string `json:"type"` // "text", "template"
+	Template string `json:"template"`
+	// Contains is text the message must contain
+	Contains string `json:"contains"`
+	// HeaderParameters and BodyParameters are the expected text parameters by their (1 based) index, e.g. {"2": "ORD-1"} for {{2}}
+	HeaderParameters map[string]string `json:"headerParameters"`
+	BodyParameters   map[string]string `json:"bodyParameters"`
+
+	// WithinSeconds is the time the business has to send the message, 0 means there is no deadline
+	WithinSeconds int       `json:"withinSeconds"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+// Status is the state of an expectation
+type Status string
+
+const (
+	StatusPending Status = "pending"
+	StatusPassed  Status = "passed"
+	StatusFailed  Status = "failed"
+)
+
+// Diff is a difference between an expectation and a message
+type Diff struct {
+	Field    string `json:"field"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+}
+
+// Result is the evaluated expectation
+type Result struct {
+	Expectation
+	Status Status `json:"status"`
+	// MessageID is the message that satisfied the expectation or, if there is no such message, the message that came closest
+	MessageID *uint `json:"messageId"`
+	// Diffs are the differences with the closest message, if there are no messages a single diff on the message field is returned
+	Diffs []Diff `json:"diffs"`
+}
+
+var (
+	lock         sync.Mutex
+	nextID       uint = 1
+	expectations      = []Expectation{}
+)
+
+// Add validates and adds an expectation
+func Add(expectation Expectation) (Expectation, error) {
+	if expectation.To != "" {
+		to, err := phonenumber.Parse(expectation.To, true)
+		if err != nil {
+			return expectation, errors.New("to is not a valid phone number")
+		}
+		expectation.To = to.Parsed
+	}
+	switch expectation.Type {
+	case "", "text", "template":
+		// Valid type
+	default:
+		return expectation, errors.New("type must be one of text or template")
+	}
+	if expectation.Type == "text" && (expectation.Template != "" || len(expectation.HeaderParameters) > 0 || len(expectation.BodyParameters) > 0) {
+		return expectation, errors.New("text messages have no template or template parameters")
+	}
+	for _, parameters := range []map[string]string{expectation.HeaderParameters, expectation.BodyParameters} {
+		for key := range parameters {
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 1 {
+				return expectation, errors.New("template parameters must be keyed by their index starting at 1")
+			}
+		}
+	}
+	if expectation.WithinSeconds < 0 {
+		return expectation, errors.New("withinSeconds cannot be negative")
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	expectation.ID = nextID
+	expectation.CreatedAt = time.Now()
+	nextID++
+	expectations = append(expectations, expectation)
+	return expectation, nil
+}
+
+// Remove removes the expectation with the id
+func Remove(id uint) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	for idx, expectation := range expectations {
+		if expectation.ID == id {
+			expectations = append(expectations[:idx], expectations[idx+1:]...)
+			return
+		}
+	}
+}
+
+// Clear removes all expectations
+func Clear() {
+	lock.Lock()
+	defer lock.Unlock()
+
+	expectations = []Expectation{}
+}
+
+// Evaluate evaluates all expectations against the messages send by the business
+func Evaluate() ([]Result, error) {
+	lock.Lock()
+	list := append([]Expectation{}, expectations...)
+	lock.Unlock()
+
+	results := []Result{}
+	for _, expectation := range list {
+		result, err := evaluate(expectation)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func evaluate(expectation Expectation) (Result, error) {
+	result := Result{Expectation: expectation, Diffs: []Diff{}}
+
+	now := time.Now()
+	var deadline *time.Time
+	if expectation.WithinSeconds > 0 {
+		value := expectation.CreatedAt.Add(time.Duration(expectation.WithinSeconds) * time.Second)
+		deadline = &value
+	}
+
+	// Messages sent after the deadline are kept so a late message is reported with a timing diff instead of as a missing message
+	query := DB.Model(&models.Message{}).Where("direction = ? AND created_at >= ?", models.DirectionIn, expectation.CreatedAt)
+	if expectation.To != "" {
+		waID := phonenumber.WaID(expectation.To)
+		conversations := DB.Model(&models.Conversation{}).Select("id").Where("phone_number = ? OR phone_number = ? OR wa_id = ?", expectation.To, waID, waID)
+		query = query.Where("conversation_id IN (?)", conversations)
+	}
+	messages := []models.Message{}
+	err := query.Order("id").Find(&messages).Error
+	if err != nil {
+		return result, err
+	}
+
+	var closest []Diff
+	for _, message := range messages {
+		diffs := compare(expectation, message)
+		if deadline != nil && message.CreatedAt.After(*deadline) {
+			diffs = append(diffs, Diff{
+				Field:    "sent at",
+				Expected: fmt.Sprintf("within %ds", expectation.WithinSeconds),
+				Actual:   fmt.Sprintf("after %ds", int(message.CreatedAt.Sub(expectation.CreatedAt).Seconds())),
+			})
+		}
+		if closest == nil || len(diffs) < len(closest) {
+			id := message.ID
+			result.MessageID = &id
+			closest = diffs
+		}
+		if len(diffs) == 0 {
+			break
+		}
+	}
+
+	switch {
+	case closest != nil && len(closest) == 0:
+		result.Status = StatusPassed
+	case deadline != nil && now.After(*deadline):
+		result.Status = StatusFailed
+	default:
+		result.Status = StatusPending
+	}
+
+	if closest == nil {
+		expected := "a message"
+		if expectation.To != "" {
+			expected += " to +" + expectation.To
+		}
+		result.Diffs = append(result.Diffs, Diff{Field: "message", Expected: expected, Actual: "no message"})
+	} else {
+		result.Diffs = append(result.Diffs, closest...)
+	}
+
+	return result, nil
+}
+
+// sendRequest is the part of the send message request used to compare template parameters
+type sendRequest struct {
+	Template struct {
+		Components []struct {
+			Type       string      `json:"type"`
+			Parameters []parameter `json:"parameters"`
+		} `json:"components"`
+	} `json:"template"`
+}
+
+// parameter is a template parameter of a send message request, only text parameters can be send
+type parameter struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// compare returns the differences between the expectation and the message
+func compare(expectation Expectation, message models.Message) []Diff {
+	diffs := []Diff{}
+
+	messageType := "text"
+	templateName := ""
+	if message.TemplateName != nil {
+		messageType = "template"
+		templateName = *message.TemplateName
+	}
+
+	if expectation.Type != "" && expectation.Type != messageType {
+		diffs = append(diffs, Diff{Field: "type", Expected: expectation.Type, Actual: messageType})
+	}
+	if expectation.Template != "" && expectation.Template != templateName {
+		diffs = append(diffs, Diff{Field: "template", Expected: expectation.Template, Actual: templateName})
+	}
+	if expectation.Contains != "" && !strings.Contains(message.Message, expectation.Contains) {
+		diffs = append(diffs, Diff{Field: "message", Expected: "contains " + strconv.Quote(expectation.Contains), Actual: message.Message})
+	}
+
+	if len(expectation.HeaderParameters) > 0 || len(expectation.BodyParameters) > 0 {
+		request := sendRequest{}
+		json.Unmarshal([]byte(message.RawRequest), &request)
+
+		parameters := map[string][]parameter{}
+		for _, component := range request.Template.Components {
+			componentType := strings.ToLower(component.Type)
+			parameters[componentType] = append(parameters[componentType], component.Parameters...)
+		}
+
+		diffs = append(diffs, compareParameters("header", expectation.HeaderParameters, parameters["header"])...)
+		diffs = append(diffs, compareParameters("body", expectation.BodyParameters, parameters["body"])...)
+	}
+
+	return diffs
+}
+
+func compareParameters(component string, expected map[string]string, actual []parameter) []Diff {
+	indexes := []int{}
+	for key := range expected {
+		index, _ := strconv.Atoi(key)
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+
+	diffs := []Diff{}
+	for _, index := range indexes {
+		key := strconv.Itoa(index)
+		actualValue := "no parameter"
+		if index <= len(actual) {
+			actualValue = actual[index-1].Text
+		}
+		if actualValue != expected[key] {
+			diffs = append(diffs, Diff{
+				Field:    fmt.Sprintf("%s parameter %s", component, key),
+				Expected: expected[key],
+				Actual:   actualValue,
+			})
+		}
+	}
+	return diffs
+}
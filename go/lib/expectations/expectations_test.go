@@ -0,0 +1,71 @@
+package expectations
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mjarkk/whatsapp-dev/go/models"
+)
+
+func TestCompareParameters(t *testing.T) {
+	templateName := "order_update"
+	message := models.Message{
+		TemplateName: &templateName,
+		RawRequest: `{"template":{"name":"order_update","components":[
+			{"type":"header","parameters":[{"type":"text","text":"Order"}]},
+			{"type":"body","parameters":[
+				{"type":"text","text":"Jane"},
+				{"type":"text","text":"ORD-1"}
+			]}
+		]}}`,
+	}
+
+	tests := []struct {
+		name     string
+		header   map[string]string
+		body     map[string]string
+		expected []Diff
+	}{
+		{
+			name:     "all parameters match",
+			header:   map[string]string{"1": "Order"},
+			body:     map[string]string{"1": "Jane", "2": "ORD-1"},
+			expected: []Diff{},
+		},
+		{
+			name: "wrong values",
+			body: map[string]string{"2": "ORD-2", "1": "John"},
+			expected: []Diff{
+				{Field: "body parameter 1", Expected: "John", Actual: "Jane"},
+				{Field: "body parameter 2", Expected: "ORD-2", Actual: "ORD-1"},
+			},
+		},
+		{
+			name:   "missing parameters",
+			header: map[string]string{"2": "x"},
+			body:   map[string]string{"10": "Jane"},
+			expected: []Diff{
+				{Field: "header parameter 2", Expected: "x", Actual: "no parameter"},
+				{Field: "body parameter 10", Expected: "Jane", Actual: "no parameter"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			diffs := compare(Expectation{HeaderParameters: test.header, BodyParameters: test.body}, message)
+			if !reflect.DeepEqual(diffs, test.expected) {
+				t.Fatalf("expected %+v, got %+v", test.expected, diffs)
+			}
+		})
+	}
+}
+
+func TestAddRejectsParameterNames(t *testing.T) {
+	for _, key := range []string{"0", "order_id"} {
+		_, err := Add(Expectation{BodyParameters: map[string]string{key: "ORD-1"}})
+		if err == nil {
+			t.Errorf("expected parameter key %q to be rejected", key)
+		}
+	}
+}
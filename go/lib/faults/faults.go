@@ -0,0 +1,163 @@
+package faults
+
+import (
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/mjarkk/whatsapp-dev/go/lib/graph"
+	"github.com/mjarkk/whatsapp-dev/go/utils/phonenumber"
+)
+
+// Fault describes a failure of the send message route, empty matchers match every message
+type Fault struct {
+	ID uint `json:"id"`
+
+	// Matchers
+	Recipient    string `json:"recipient"`
+	Type         string `json:"type"` // "text", "template"
+	TemplateName string `json:"templateName"`
+
+	// Remaining is the amount of matching messages that still fail, 0 means every matching message fails
+	Remaining int `json:"remaining"`
+	// Percentage is the chance a matching message fails, 0 means every matching message fails
+	Percentage int `json:"percentage"`
+
+	// Code is the error code returned, 0 means no error is returned
+	Code    int    `json:"code"`
+	Details string `json:"details"`
+	// DelayMs delays the response of matching messages
+	DelayMs int `json:"delayMs"`
+}
+
+// Message is the message that is being sent
+type Message struct {
+	Recipient    string
+	Type         string
+	TemplateName string
+}
+
+var (
+	lock   sync.Mutex
+	nextID uint = 1
+	faults      = []Fault{}
+	random      = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+// List returns all active faults
+func List() []Fault {
+	lock.Lock()
+	defer lock.Unlock()
+
+	return append([]Fault{}, faults...)
+}
+
+// Add validates and adds a fault
+func Add(fault Fault) (Fault, error) {
+	if fault.Recipient != "" {
+		recipient, err := phonenumber.Parse(fault.Recipient, false)
+		if err != nil {
+			return fault, errors.New("recipient is not a valid phone number")
+		}
+		fault.Recipient = recipient.Parsed
+	}
+	switch fault.Type {
+	case "", "text", "template":
+		// Valid type
+	default:
+		return fault, errors.New("type must be one of text or template")
+	}
+	if fault.Remaining < 0 {
+		return fault, errors.New("remaining cannot be negative")
+	}
+	if fault.Percentage < 0 || fault.Percentage > 100 {
+		return fault, errors.New("percentage must be between 0 and 100")
+	}
+	if fault.Code < 0 {
+		return fault, errors.New("code cannot be negative")
+	}
+	if fault.DelayMs < 0 {
+		return fault, errors.New("delayMs cannot be negative")
+	}
+	if fault.Code == 0 && fault.DelayMs == 0 {
+		return fault, errors.New("a fault requires a code or a delay")
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	fault.ID = nextID
+	nextID++
+	faults = append(faults, fault)
+	return fault, nil
+}
+
+// Clear removes all faults
+func Clear() {
+	lock.Lock()
+	defer lock.Unlock()
+
+	faults = []Fault{}
+}
+
+// Remove removes the fault with the id
+func Remove(id uint) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	for idx, fault := range faults {
+		if fault.ID == id {
+			faults = append(faults[:idx], faults[idx+1:]...)
+			return
+		}
+	}
+}
+
+func (f Fault) matches(message Message) bool {
+	if f.Recipient != "" && f.Recipient != message.Recipient {
+		return false
+	}
+	if f.Type != "" && f.Type != message.Type {
+		return false
+	}
+	if f.TemplateName != "" && f.TemplateName != message.TemplateName {
+		return false
+	}
+	return true
+}
+
+// Apply waits for the delays of the faults matching the message and returns the error of the first matching fault with a code
+func Apply(message Message) error {
+	lock.Lock()
+
+	var delay time.Duration
+	var err error
+	remainingFaults := []Fault{}
+	for _, fault := range faults {
+		if !fault.matches(message) || (fault.Percentage > 0 && random.Intn(100) >= fault.Percentage) {
+			remainingFaults = append(remainingFaults, fault)
+			continue
+		}
+
+		delay += time.Duration(fault.DelayMs) * time.Millisecond
+		if err == nil && fault.Code != 0 {
+			err = graph.KnownError(fault.Code, fault.Details)
+		}
+
+		if fault.Remaining == 1 {
+			// This was the last message this fault applies to
+			continue
+		}
+		if fault.Remaining > 1 {
+			fault.Remaining--
+		}
+		remainingFaults = append(remainingFaults, fault)
+	}
+	faults = remainingFaults
+
+	lock.Unlock()
+
+	time.Sleep(delay)
+	return err
+}
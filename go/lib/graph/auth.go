@@ -0,0 +1,125 @@
+package graph
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/mjarkk/whatsapp-dev/go/models"
+)
+
+const accessTokenLocalsKey = "graphAccessToken"
+
+// Authenticate validates the access token of a graph api request.
+// The token can be send as bearer token, as OAuth token (used by the upload api) or using the access_token query or form parameter.
+// Form parameters can be url encoded or multipart, batch requests are often send as multipart form.
+func Authenticate(c *fiber.Ctx) error {
+	authHeader := c.Get(fiber.HeaderAuthorization)
+	if authHeader == "" {
+		token := c.Query("access_token")
+		contentType := c.Get(fiber.HeaderContentType)
+		if token == "" && (strings.HasPrefix(contentType, fiber.MIMEApplicationForm) || strings.HasPrefix(contentType, fiber.MIMEMultipartForm)) {
+			token = c.FormValue("access_token")
+		}
+		if token == "" {
+			return KindError(AuthTokenMissingAuthKind)
+		}
+		authHeader = "Bearer " + token
+	}
+
+	_, token, found := strings.Cut(authHeader, "Bearer ")
+	if !found {
+		_, token, found = strings.Cut(authHeader, "OAuth ")
+	}
+	if !found {
+		return KindError(AuthTokenInvalidAuthKind)
+	}
+
+	accessToken, err := models.FindAccessToken(token)
+	if err != nil {
+		return KindError(AuthTokenMalformed)
+	}
+	tokenErr := TokenError(accessToken)
+	if tokenErr != nil {
+		return tokenErr
+	}
+
+	c.Locals(accessTokenLocalsKey, accessToken)
+	return nil
+}
+
+// AccessToken returns the access token of an authenticated request
+func AccessToken(c *fiber.Ctx) *models.AccessToken {
+	accessToken, _ := c.Locals(accessTokenLocalsKey).(*models.AccessToken)
+	return accessToken
+}
+
+const facebookTimeFormat = "Monday, 02-Jan-06 15:04:05 MST"
+
+// TokenError returns the error for a token that is expired or revoked, nil is returned for valid tokens
+func TokenError(token *models.AccessToken) *Error {
+	tokenErr := &Error{
+		Status: 401,
+		Type:   "OAuthException",
+		Code:   190,
+	}
+
+	if token.RevokedAt != nil {
+		reason := models.RevokeReasonInvalidated
+		if token.RevokeReason != nil {
+			reason = *token.RevokeReason
+		}
+
+		switch reason {
+		case models.RevokeReasonPasswordChanged:
+			tokenErr.Subcode = 460
+			tokenErr.Message = "Error validating access token: The session has been invalidated because the user changed their password or Facebook has changed the session for security reasons."
+		case models.RevokeReasonAppRemoved:
+			tokenErr.Subcode = 458
+			tokenErr.Message = "Error validating access token: The user has not authorized application."
+		default:
+			tokenErr.Subcode = 467
+			tokenErr.Message = "Error validating access token: The session is invalid because the user logged out."
+		}
+		return tokenErr
+	}
+
+	if token.IsExpired() {
+		tokenErr.Subcode = 463
+		tokenErr.Message = "Error validating access token: Session has expired on " + token.ExpiresAt.Format(facebookTimeFormat) + ". The current time is " + time.Now().Format(facebookTimeFormat) + "."
+		return tokenErr
+	}
+
+	return nil
+}
+
+// RequireScope returns a middleware that authenticates the request and validates the access token has scope.
+// If scope is empty only the access token is validated.
+func RequireScope(scope string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		err := Authenticate(c)
+		if err != nil {
+			return err
+		}
+
+		err = CheckScope(c, scope)
+		if err != nil {
+			return err
+		}
+
+		return c.Next()
+	}
+}
+
+// CheckScope returns an error if the access token of an authenticated request does not have scope
+func CheckScope(c *fiber.Ctx, scope string) error {
+	if scope != "" && !AccessToken(c).HasScope(scope) {
+		return &Error{
+			Status:  403,
+			Message: "(#200) Requires " + scope + " permission to manage the object",
+			Type:    "OAuthException",
+			Code:    200,
+		}
+	}
+	return nil
+}
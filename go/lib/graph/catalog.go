@@ -0,0 +1,113 @@
+package graph
+
+// CatalogEntry describes an error code the cloud api is known to return
+type CatalogEntry struct {
+	Code int `json:"code"`
+	// Subcode is the error_subcode the real api returns with the code, 0 means the real api omits error_subcode
+	Subcode     int    `json:"subcode,omitempty"`
+	Status      int    `json:"status"`
+	Message     string `json:"message"`
+	UserTitle   string `json:"userTitle"`
+	UserMessage string `json:"userMessage"`
+	Transient   bool   `json:"transient"`
+}
+
+// Catalog contains the error codes of the whatsapp cloud api
+// See: https://developers.facebook.com/docs/whatsapp/cloud-api/support/error-codes
+var Catalog = []CatalogEntry{
+	// Authorization errors
+	{0, 0, 401, "(#0) AuthException", "Authentication error", "We were unable to authenticate the app user.", false},
+	{3, 0, 400, "(#3) Capability or permissions issue", "API method error", "The app does not have the capability to use this API method.", false},
+	{10, 0, 403, "(#10) Permission denied", "Permission denied", "Permission is either not granted or has been removed.", false},
+	{190, 463, 401, "Access token has expired", "Access token expired", "Your access token has expired.", false},
+
+	// Throttling errors
+	{4, 0, 400, "(#4) Application request limit reached", "Too many API calls", "The app has reached its API call rate limit.", true},
+	{80007, 0, 400, "(#80007) There have been too many calls to this WhatsApp Business account. Wait a bit and try again. For more info, please refer to https://developers.facebook.com/docs/graph-api/overview/rate-limiting.", "Rate limit issues", "The WhatsApp Business Account has reached its rate limit.", true},
+	{130429, 0, 400, "(#130429) Rate limit hit", "Rate limit hit", "Cloud API message throughput has been reached.", true},
+	{131048, 0, 400, "(#131048) Spam rate limit hit", "Spam rate limit hit", "Message failed to send because there are restrictions on how many messages can be sent from this phone number.", true},
+	{131056, 0, 400, "(#131056) (Business Account, Consumer Account) pair rate limit hit", "Pair rate limit hit", "Message failed to send because there were too many messages sent from this phone number to the same phone number in a short period of time.", true},
+
+	// Integrity errors
+	{368, 0, 400, "(#368) Temporarily blocked for policies violations", "Temporarily blocked", "The WhatsApp Business Account associated with the app has been restricted or disabled for violating a platform policy.", false},
+	{130497, 0, 400, "(#130497) Business account is restricted from messaging users in this country", "Country restricted", "The WhatsApp Business Account is restricted from messaging users in certain countries.", false},
+	{131031, 0, 400, "(#131031) Business Account locked", "Account locked", "The WhatsApp Business Account associated with the app has been restricted or disabled for violating a platform policy.", false},
+
+	// Generic errors
+	{1, 0, 500, "(#1) An unknown error occurred", "API Unknown", "Invalid request or possible server error.", true},
+	{2, 0, 500, "(#2) Service temporarily unavailable", "API Service", "Temporary due to downtime or due to being overloaded.", true},
+	{33, 0, 400, "(#33) Parameter value is not valid", "Parameter value is not valid", "The business phone number has been deleted.", false},
+	{100, 0, 400, "(#100) Invalid parameter", "Invalid parameter", "The request included one or more unsupported or misspelled parameters.", false},
+	{130472, 0, 400, "(#130472) User's number is part of an experiment", "User's number is part of an experiment", "Message was not sent as part of an experiment.", false},
+	{131000, 0, 500, "(#131000) Something went wrong", "Something went wrong", "Message failed to send due to an unknown error.", true},
+	{131005, 0, 403, "(#131005) Access denied", "Access denied", "Permission is either not granted or has been removed.", false},
+	{131008, 0, 400, "(#131008) Required parameter is missing", "Required parameter is missing", "The request is missing a required parameter.", false},
+	{131009, 2494055, 400, "(#131009) Parameter value is not valid", "Parameter value is not valid", "One or more parameter values are invalid.", false},
+	{131016, 0, 503, "(#131016) Service unavailable", "Service unavailable", "A service is temporarily unavailable.", true},
+	{131021, 0, 400, "(#131021) Recipient cannot be sender", "Recipient cannot be sender", "Sender and recipient phone number is the same.", false},
+	{131026, 0, 400, "(#131026) Message Undeliverable", "Message Undeliverable", "Unable to deliver message.", false},
+	{131030, 0, 400, "(#131030) Recipient phone number not in allowed list", "Recipient phone number not in allowed list", "Recipient phone number not in allowed list.", false},
+	{131037, 0, 400, "(#131037) WhatsApp provided number needs display name approval before message can be sent.", "Display name approval needed", "The 555 business phone number needs a display name approval before it can send messages.", false},
+	{131042, 0, 400, "(#131042) Business eligibility payment issue", "Business eligibility payment issue", "There was an error related to your payment method.", false},
+	{131045, 0, 400, "(#131045) Incorrect certificate", "Incorrect certificate", "Message failed to send due to a phone number registration error.", false},
+	{131047, 0, 400, "(#131047) Re-engagement message", "Re-engagement message", "More than 24 hours have passed since the recipient last replied to the sender number.", false},
+	{131049, 0, 400, "(#131049) This message was not delivered to maintain healthy ecosystem engagement.", "Meta chose not to deliver", "This message was not delivered to maintain healthy ecosystem engagement.", false},
+	{131050, 0, 400, "(#131050) Unable to deliver the message. This recipient has chosen to stop receiving marketing messages on WhatsApp from your business.", "User stopped marketing messages", "The recipient has chosen to stop receiving marketing messages on WhatsApp from your business.", false},
+	{131051, 0, 400, "(#131051) Unsupported message type", "Unsupported message type", "Unsupported message type.", false},
+	{131052, 0, 400, "(#131052) Media download error", "Media download error", "Unable to download the media sent by the user.", false},
+	{131053, 0, 400, "(#131053) Media upload error", "Media upload error", "Unable to upload the media used in the message.", false},
+	{131057, 0, 400, "(#131057) Account in maintenance mode", "Account in maintenance mode", "Business Account is in maintenance mode.", true},
+
+	// Template errors
+	{132000, 0, 400, "(#132000) Number of parameters does not match the expected number of params", "Template param count mismatch", "The number of variable parameter values included in the request did not match the number of variable parameters defined in the template.", false},
+	{132001, 0, 400, "(#132001) Template name does not exist in the translation", "Template does not exist", "The template does not exist in the specified language or the template has not been approved.", false},
+	{132005, 0, 400, "(#132005) Translated text too long", "Template hydrated text too long", "Translated text is too long.", false},
+	{132007, 0, 400, "(#132007) Template Format Character Policy Violated", "Template format character policy violated", "Template content violates a WhatsApp policy.", false},
+	{132012, 0, 400, "(#132012) Parameter format does not match format in the created template", "Template parameter format mismatch", "Variable parameter values formatted incorrectly.", false},
+	{132015, 0, 400, "(#132015) Template is Paused", "Template is paused", "Template is paused due to low quality so it cannot be sent in a template message.", false},
+	{132016, 0, 400, "(#132016) Template is Disabled", "Template is disabled", "Template has been paused too many times due to low quality and is now permanently disabled.", false},
+	{132068, 0, 400, "(#132068) Flow is blocked", "Flow is blocked", "Flow is in blocked state.", false},
+	{132069, 0, 400, "(#132069) Flow is throttled", "Flow is throttled", "Flow is in throttled state and 10 messages using this flow were already sent in the last hour.", false},
+
+	// Registration errors
+	{133000, 0, 400, "(#133000) Incomplete Deregistration", "Incomplete deregistration", "A previous deregistration attempt failed.", false},
+	{133004, 0, 503, "(#133004) Server Temporarily Unavailable", "Server temporarily unavailable", "Server is temporarily unavailable.", true},
+	{133005, 0, 400, "(#133005) Two step verification PIN Mismatch", "Two step verification PIN mismatch", "Two step verification PIN incorrect.", false},
+	{133006, 0, 400, "(#133006) Phone number re-verification needed", "Phone number re-verification needed", "Phone number needs to be verified before registering.", false},
+	{133008, 0, 400, "(#133008) Too many two step verification PIN guesses", "Too many two step verification PIN guesses", "Too many two step verification PIN guesses for this phone number.", true},
+	{133009, 0, 400, "(#133009) Two step verification PIN Guessed Too Fast", "Two step verification PIN guessed too fast", "Two step verification PIN was entered too quickly.", true},
+	{133010, 0, 400, "(#133010) Account not registered", "Phone number not registered", "Phone number not registered on the WhatsApp Business Platform.", false},
+	{133015, 0, 400, "(#133015) Please wait a few minutes before attempting to register this phone number", "Please wait a few minutes", "The phone number you are attempting to register was recently deleted, and deletion has not yet completed.", true},
+	{135000, 0, 400, "(#135000) Generic user error", "Generic user error", "Message failed to send because of an unknown error with your request parameters.", false},
+	{136025, 0, 400, "(#136025) Verify code error", "Verify code error", "Verification code is incorrect or has expired.", false},
+}
+
+// FindCatalogEntry returns the catalog entry of an error code
+func FindCatalogEntry(code int) (CatalogEntry, bool) {
+	for _, entry := range Catalog {
+		if entry.Code == code {
+			return entry, true
+		}
+	}
+	return CatalogEntry{}, false
+}
+
+// KnownError returns the error for a code in the catalog, the user message is used as details if none are given.
+// Codes that are not in the catalog result in a generic error with the code.
+func KnownError(code int, details ...string) *Error {
+	entry, ok := FindCatalogEntry(code)
+	if !ok {
+		return CodeError(code, "WhatsApp-Dev simulated error", details...)
+	}
+
+	if len(details) == 0 || details[0] == "" {
+		details = []string{entry.UserMessage}
+	}
+	err := CodeError(code, entry.Message, details...)
+	err.Status = entry.Status
+	err.Subcode = entry.Subcode
+	err.UserTitle = entry.UserTitle
+	err.UserMessage = entry.UserMessage
+	err.Transient = entry.Transient
+	return err
+}
@@ -0,0 +1,175 @@
+package graph
+
+import (
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/mjarkk/whatsapp-dev/go/utils/random"
+)
+
+// Error is an error as returned by the facebook graph api
+type Error struct {
+	Status  int
+	Message string
+	Type    string
+	Code    int
+	Subcode int
+	Details string
+
+	UserTitle   string
+	UserMessage string
+	Transient   bool
+}
+
+var (
+	traceIDLock   sync.Mutex
+	traceIDSource = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+// newTraceID returns a random fbtrace_id
+func newTraceID() string {
+	traceIDLock.Lock()
+	defer traceIDLock.Unlock()
+	return random.Alphanumeric(traceIDSource, 27)
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Send writes the error in the same format as the graph api does
+func (e *Error) Send(c *fiber.Ctx) error {
+	if e.Type == "OAuthException" {
+		c.Response().Header.Set("www-authenticate", `OAuth "Facebook Platform" "invalid_request" "`+e.Message+`"`)
+	}
+
+	errData := map[string]any{
+		"message":      e.Message,
+		"type":         e.Type,
+		"code":         e.Code,
+		"is_transient": e.Transient,
+		"fbtrace_id":   newTraceID(),
+	}
+	if e.Subcode != 0 {
+		errData["error_subcode"] = e.Subcode
+	}
+	if e.UserTitle != "" {
+		errData["error_user_title"] = e.UserTitle
+	}
+	if e.UserMessage != "" {
+		errData["error_user_msg"] = e.UserMessage
+	}
+	if e.Details != "" {
+		errData["error_data"] = map[string]any{
+			"messaging_product": "whatsapp",
+			"details":           e.Details,
+		}
+	}
+
+	return c.Status(e.Status).JSON(map[string]any{"error": errData})
+}
+
+// ErrorMiddleware sends graph api errors returned by the routes after it
+func ErrorMiddleware(c *fiber.Ctx) error {
+	err := c.Next()
+
+	var graphErr *Error
+	if errors.As(err, &graphErr) {
+		return graphErr.Send(c)
+	}
+
+	return err
+}
+
+type ErrorKind uint8
+
+const (
+	AuthTokenMalformed ErrorKind = iota
+	AuthTokenInvalidAuthKind
+	AuthTokenMissingAuthKind
+	AuthTokenCannotBeDecrypted
+	AuthTokenInvalidContentType
+)
+
+func ErrValues(kind ErrorKind) (status int, code int, message string) {
+	switch kind {
+	case AuthTokenMalformed:
+		return 400, 190, "Malformed access token"
+	case AuthTokenInvalidAuthKind:
+		return 401, 190, "Invalid auth type in access token"
+	case AuthTokenMissingAuthKind:
+		return 400, 190, "Missing authentication header"
+	case AuthTokenCannotBeDecrypted:
+		return 401, 190, "The access token could not be decrypted"
+	case AuthTokenInvalidContentType:
+		return 400, 190, "Invalid content type (application/json)"
+	default:
+		return 400, 102, "Unknown error kind"
+	}
+}
+
+// KindError returns the error for one of the predefined error kinds
+func KindError(kind ErrorKind) *Error {
+	status, code, message := ErrValues(kind)
+	return &Error{
+		Status:  status,
+		Message: message,
+		Type:    "OAuthException",
+		Code:    code,
+	}
+}
+
+// CustomError returns an invalid parameter error (#100) with a custom message
+func CustomError(message string, details ...string) *Error {
+	err := &Error{
+		Status:  400,
+		Message: message,
+		Type:    "OAuthException",
+		Code:    100,
+	}
+	if len(details) > 0 {
+		err.Details = details[0]
+	}
+	return err
+}
+
+// CodeError returns an error with a whatsapp specific error code
+func CodeError(code int, message string, details ...string) *Error {
+	err := CustomError(message, details...)
+	err.Code = code
+	return err
+}
+
+// UnknownObjectError is returned when a request is made to an object id that does not exist
+func UnknownObjectError(method string, id string) *Error {
+	return &Error{
+		Status:  400,
+		Message: "Unsupported " + method + " request. Object with ID '" + id + "' does not exist, cannot be loaded due to missing permissions, or does not support this operation. Please read the Graph API documentation at https://developers.facebook.com/docs/graph-api",
+		Type:    "GraphMethodException",
+		Code:    100,
+		Subcode: 33,
+	}
+}
+
+// DeprecatedVersionError is returned when a sunset graph api version is called
+func DeprecatedVersionError(called Version, latest Version) *Error {
+	return &Error{
+		Status:  400,
+		Message: "(#2635) You are calling a deprecated version of the Graph API (" + called.String() + "). Please update to the latest version: " + latest.String() + ".",
+		Type:    "OAuthException",
+		Code:    2635,
+	}
+}
+
+// UnknownPathError is returned when a request is made to a path that does not exist
+func UnknownPathError(path string) *Error {
+	return &Error{
+		Status:  400,
+		Message: "Unknown path components: " + path,
+		Type:    "OAuthException",
+		Code:    2500,
+	}
+}
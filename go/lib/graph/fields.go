@@ -0,0 +1,152 @@
+package graph
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Field is a field selected using the fields query parameter, nested fields are selected using curly braces
+type Field struct {
+	Name   string
+	Fields []Field
+}
+
+// ParseFields parses a fields query like "id,name,whatsapp_business_profile{about,email}"
+func ParseFields(query string) ([]Field, error) {
+	fields, end, err := parseFields(query, 0)
+	if err != nil {
+		return nil, err
+	}
+	if end < len(query) {
+		return nil, fieldsSyntaxError(query, end)
+	}
+	return fields, nil
+}
+
+func parseFields(query string, start int) ([]Field, int, error) {
+	fields := []Field{}
+	idx := start
+	for idx < len(query) {
+		nameStart := idx
+		for idx < len(query) && !strings.ContainsRune(",{}.", rune(query[idx])) {
+			idx++
+		}
+		name := strings.TrimSpace(query[nameStart:idx])
+		if name == "" {
+			return nil, idx, fieldsSyntaxError(query, idx)
+		}
+		field := Field{Name: name}
+
+		if idx < len(query) && query[idx] == '.' {
+			// Skip modifiers like .limit(10), they are not supported by the emulator
+			closing := strings.IndexByte(query[idx:], ')')
+			if closing == -1 {
+				return nil, idx, fieldsSyntaxError(query, idx)
+			}
+			idx += closing + 1
+		}
+
+		if idx < len(query) && query[idx] == '{' {
+			nested, end, err := parseFields(query, idx+1)
+			if err != nil {
+				return nil, end, err
+			}
+			if end >= len(query) || query[end] != '}' {
+				return nil, end, fieldsSyntaxError(query, end)
+			}
+			field.Fields = nested
+			idx = end + 1
+		}
+		fields = append(fields, field)
+
+		if idx >= len(query) || query[idx] == '}' {
+			return fields, idx, nil
+		}
+		if query[idx] != ',' {
+			return nil, idx, fieldsSyntaxError(query, idx)
+		}
+		idx++
+		if idx >= len(query) {
+			// Trailing comma
+			return nil, idx, fieldsSyntaxError(query, idx)
+		}
+	}
+	return fields, idx, nil
+}
+
+func fieldsSyntaxError(query string, position int) *Error {
+	if position >= len(query) {
+		return CustomError(fmt.Sprintf(`(#100) Syntax error "Unexpected end of string." at character %d: %s`, position, query))
+	}
+	return CustomError(fmt.Sprintf(`(#100) Syntax error "Expected end of string instead of \"%c\"." at character %d: %s`, query[position], position, query))
+}
+
+// Node is a graph api object of which the fields can be selected
+type Node struct {
+	Type string
+	// Fields contains the values of the fields, values can be nodes, edges ([]Node) or nil if the field is not set
+	Fields map[string]any
+	// Defaults are the fields returned if no fields are selected
+	Defaults []string
+}
+
+// Select returns the selected fields of the node, the default fields are returned if no fields are selected.
+// Like the graph api the id is always returned.
+func (n Node) Select(fields []Field) (map[string]any, error) {
+	if len(fields) == 0 {
+		for _, name := range n.Defaults {
+			fields = append(fields, Field{Name: name})
+		}
+	}
+
+	result := map[string]any{}
+	for _, field := range fields {
+		value, ok := n.Fields[field.Name]
+		if !ok {
+			return nil, CustomError(fmt.Sprintf("(#100) Tried accessing nonexisting field (%s) on node type (%s)", field.Name, n.Type))
+		}
+
+		switch typedValue := value.(type) {
+		case nil:
+			continue
+		case Node:
+			selected, err := typedValue.Select(field.Fields)
+			if err != nil {
+				return nil, err
+			}
+			result[field.Name] = selected
+		case []Node:
+			data := []map[string]any{}
+			for _, node := range typedValue {
+				selected, err := node.Select(field.Fields)
+				if err != nil {
+					return nil, err
+				}
+				data = append(data, selected)
+			}
+			result[field.Name] = map[string]any{"data": data}
+		default:
+			if len(field.Fields) > 0 {
+				return nil, CustomError(fmt.Sprintf("(#100) Field (%s) on node type (%s) has no subfields", field.Name, n.Type))
+			}
+			result[field.Name] = value
+		}
+	}
+
+	if id, ok := n.Fields["id"]; ok && id != nil {
+		result["id"] = id
+	}
+
+	return result, nil
+}
+
+// SelectFields returns the fields of the node selected by the fields query parameter
+func SelectFields(c *fiber.Ctx, node Node) (map[string]any, error) {
+	fields, err := ParseFields(c.Query("fields"))
+	if err != nil {
+		return nil, err
+	}
+	return node.Select(fields)
+}
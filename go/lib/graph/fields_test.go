@@ -0,0 +1,190 @@
+package graph
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseFields(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected []Field
+		// err is a part of the expected error message, empty means no error is expected
+		err string
+	}{
+		{query: "", expected: []Field{}},
+		{query: "id", expected: []Field{{Name: "id"}}},
+		{query: "id,name", expected: []Field{{Name: "id"}, {Name: "name"}}},
+		{query: " id , name ", expected: []Field{{Name: "id"}, {Name: "name"}}},
+		{
+			query: "verified_name,whatsapp_business_profile{about,email}",
+			expected: []Field{
+				{Name: "verified_name"},
+				{Name: "whatsapp_business_profile", Fields: []Field{{Name: "about"}, {Name: "email"}}},
+			},
+		},
+		{
+			query: "a{b{c,d},e},f",
+			expected: []Field{
+				{Name: "a", Fields: []Field{
+					{Name: "b", Fields: []Field{{Name: "c"}, {Name: "d"}}},
+					{Name: "e"},
+				}},
+				{Name: "f"},
+			},
+		},
+		{
+			query:    "messages.limit(10){id},name",
+			expected: []Field{{Name: "messages", Fields: []Field{{Name: "id"}}}, {Name: "name"}},
+		},
+		{query: "a,,b", err: `Expected end of string instead of \",\".`},
+		{query: "a,", err: "Unexpected end of string."},
+		{query: ",a", err: "at character 0"},
+		{query: "a{b", err: "Unexpected end of string."},
+		{query: "a{}", err: `instead of \"}\"`},
+		{query: "a}", err: `instead of \"}\".`},
+		{query: "a{b}}", err: "at character 4"},
+		{query: "a.limit(10", err: "at character 1"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			fields, err := ParseFields(test.query)
+			if test.err != "" {
+				assertGraphError(t, err, 100, test.err)
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err.Error())
+			}
+			if !reflect.DeepEqual(fields, test.expected) {
+				t.Fatalf("expected %+v, got %+v", test.expected, fields)
+			}
+		})
+	}
+}
+
+func TestNodeSelect(t *testing.T) {
+	profile := Node{
+		Type:     "WhatsAppBusinessProfile",
+		Fields:   map[string]any{"about": "Hi", "email": "info@example.com", "websites": nil},
+		Defaults: []string{"about"},
+	}
+	template := func(id string) Node {
+		return Node{
+			Type:     "WhatsAppMessageTemplate",
+			Fields:   map[string]any{"id": id, "name": "hello_world", "status": "APPROVED"},
+			Defaults: []string{"id", "name"},
+		}
+	}
+	node := Node{
+		Type: "WhatsAppBusinessPhoneNumber",
+		Fields: map[string]any{
+			"id":                        "1234",
+			"verified_name":             "Test",
+			"quality_rating":            "GREEN",
+			"whatsapp_business_profile": profile,
+			"message_templates":         []Node{template("1"), template("2")},
+			"throughput":                nil,
+		},
+		Defaults: []string{"verified_name", "quality_rating", "throughput"},
+	}
+
+	tests := []struct {
+		name     string
+		query    string
+		expected map[string]any
+		err      string
+	}{
+		{
+			name:     "defaults",
+			query:    "",
+			expected: map[string]any{"id": "1234", "verified_name": "Test", "quality_rating": "GREEN"},
+		},
+		{
+			name:     "id is always returned",
+			query:    "verified_name",
+			expected: map[string]any{"id": "1234", "verified_name": "Test"},
+		},
+		{
+			name:  "nested node defaults",
+			query: "whatsapp_business_profile",
+			expected: map[string]any{
+				"id":                        "1234",
+				"whatsapp_business_profile": map[string]any{"about": "Hi"},
+			},
+		},
+		{
+			name:  "nested node fields",
+			query: "whatsapp_business_profile{email,websites}",
+			expected: map[string]any{
+				"id":                        "1234",
+				"whatsapp_business_profile": map[string]any{"email": "info@example.com"},
+			},
+		},
+		{
+			name:  "edge",
+			query: "message_templates{status}",
+			expected: map[string]any{
+				"id": "1234",
+				"message_templates": map[string]any{"data": []map[string]any{
+					{"id": "1", "status": "APPROVED"},
+					{"id": "2", "status": "APPROVED"},
+				}},
+			},
+		},
+		{
+			name:  "unknown field",
+			query: "nope",
+			err:   "Tried accessing nonexisting field (nope) on node type (WhatsAppBusinessPhoneNumber)",
+		},
+		{
+			name:  "unknown nested field",
+			query: "whatsapp_business_profile{nope}",
+			err:   "Tried accessing nonexisting field (nope) on node type (WhatsAppBusinessProfile)",
+		},
+		{
+			name:  "subfields of a value",
+			query: "verified_name{nope}",
+			err:   "Field (verified_name) on node type (WhatsAppBusinessPhoneNumber) has no subfields",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fields, err := ParseFields(test.query)
+			if err != nil {
+				t.Fatalf("unexpected parse error: %s", err.Error())
+			}
+
+			result, err := node.Select(fields)
+			if test.err != "" {
+				assertGraphError(t, err, 100, test.err)
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err.Error())
+			}
+			if !reflect.DeepEqual(result, test.expected) {
+				t.Fatalf("expected %+v, got %+v", test.expected, result)
+			}
+		})
+	}
+}
+
+func assertGraphError(t *testing.T, err error, code int, message string) {
+	t.Helper()
+
+	var graphErr *Error
+	if !errors.As(err, &graphErr) {
+		t.Fatalf("expected a graph error containing %q, got %v", message, err)
+	}
+	if graphErr.Code != code {
+		t.Fatalf("expected error code %d, got %d", code, graphErr.Code)
+	}
+	if !strings.Contains(graphErr.Message, message) {
+		t.Fatalf("expected error containing %q, got %q", message, graphErr.Message)
+	}
+}
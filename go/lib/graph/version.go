@@ -0,0 +1,173 @@
+package graph
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/mjarkk/whatsapp-dev/go/state"
+)
+
+// Version is a graph api version, for example v18.0
+type Version struct {
+	Major int
+	Minor int
+}
+
+// ParseVersion parses a version like "v18.0" or "18.0"
+func ParseVersion(value string) (Version, error) {
+	parts := strings.Split(strings.TrimPrefix(value, "v"), ".")
+	if len(parts) > 2 {
+		return Version{}, errors.New("invalid version " + value)
+	}
+
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return Version{}, errors.New("invalid version " + value)
+	}
+	version := Version{Major: major}
+	if len(parts) == 2 {
+		version.Minor, err = strconv.Atoi(parts[1])
+		if err != nil {
+			return Version{}, errors.New("invalid version " + value)
+		}
+	}
+	return version, nil
+}
+
+func (v Version) String() string {
+	return fmt.Sprintf("v%d.%d", v.Major, v.Minor)
+}
+
+// AtLeast returns true if v is the same or a later version than other
+func (v Version) AtLeast(other Version) bool {
+	if v.Major != other.Major {
+		return v.Major > other.Major
+	}
+	return v.Minor >= other.Minor
+}
+
+// SupportedVersion is an entry in the versions table
+type SupportedVersion struct {
+	Version string `json:"version"`
+	// Sunset versions result in a deprecation error
+	Sunset bool `json:"sunset"`
+}
+
+// Versions is the table of graph api versions known to the emulator
+var Versions = state.State[[]SupportedVersion]{}
+
+// DefaultVersions returns the versions table used on startup
+func DefaultVersions() []SupportedVersion {
+	versions := []SupportedVersion{}
+	for major := 11; major <= 24; major++ {
+		versions = append(versions, SupportedVersion{
+			Version: fmt.Sprintf("v%d.0", major),
+			Sunset:  major < 18,
+		})
+	}
+	return versions
+}
+
+// ValidateVersions checks that all versions in the table can be parsed and at least one version is not sunset
+func ValidateVersions(versions []SupportedVersion) error {
+	available := false
+	for _, version := range versions {
+		_, err := ParseVersion(version.Version)
+		if err != nil {
+			return err
+		}
+		if !version.Sunset {
+			available = true
+		}
+	}
+	if !available {
+		return errors.New("at least one version must not be sunset")
+	}
+	return nil
+}
+
+// latestVersion returns the latest version that is not sunset
+func latestVersion(versions []SupportedVersion) Version {
+	latest := Version{}
+	for _, entry := range versions {
+		version, err := ParseVersion(entry.Version)
+		if err == nil && !entry.Sunset && version.AtLeast(latest) {
+			latest = version
+		}
+	}
+	return latest
+}
+
+// LatestVersion returns the latest version of the versions table that is not sunset
+func LatestVersion() Version {
+	return latestVersion(Versions.Get())
+}
+
+// VersionedField is a field that is only available from a graph api version on
+type VersionedField struct {
+	Since Version
+	// Source documents the version the field was added in
+	Source string
+}
+
+// VersionedFields contains the fields added in later graph api versions by node type and field name
+var VersionedFields = map[string]map[string]VersionedField{
+	"WhatsAppBusinessPhoneNumber": {
+		"health_status": {
+			Since:  Version{Major: 19},
+			Source: "https://developers.facebook.com/docs/whatsapp/cloud-api/health-status",
+		},
+	},
+}
+
+// SupportsField returns true if the called graph api version supports the field of the node type
+func SupportsField(c *fiber.Ctx, nodeType string, field string) bool {
+	versionedField, ok := VersionedFields[nodeType][field]
+	if !ok {
+		return true
+	}
+	return CalledVersion(c).AtLeast(versionedField.Since)
+}
+
+const versionLocalsKey = "graphVersion"
+
+// VersionMiddleware validates the graph api version in the url against the versions table
+func VersionMiddleware(c *fiber.Ctx) error {
+	version, err := ParseVersion(c.Params("version"))
+	if err != nil {
+		return UnknownPathError(c.Path())
+	}
+
+	versions := Versions.Get()
+	var entry *SupportedVersion
+	for idx, supportedVersion := range versions {
+		parsed, err := ParseVersion(supportedVersion.Version)
+		if err == nil && parsed == version {
+			entry = &versions[idx]
+			break
+		}
+	}
+	if entry == nil {
+		return UnknownPathError(c.Path())
+	}
+
+	c.Response().Header.Set("facebook-api-version", version.String())
+	if entry.Sunset {
+		return DeprecatedVersionError(version, latestVersion(versions))
+	}
+
+	c.Locals(versionLocalsKey, version)
+	return c.Next()
+}
+
+// CalledVersion returns the graph api version of the request
+func CalledVersion(c *fiber.Ctx) Version {
+	version, ok := c.Locals(versionLocalsKey).(Version)
+	if !ok {
+		return LatestVersion()
+	}
+	return version
+}
@@ -0,0 +1,143 @@
+package ratelimit
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/mjarkk/whatsapp-dev/go/lib/graph"
+	"github.com/mjarkk/whatsapp-dev/go/state"
+)
+
+// Config contains the rate limits of the send message route, a limit of 0 disables it
+type Config struct {
+	// MessagesPerSecond is the throughput of a single business phone number
+	MessagesPerSecond int `json:"messagesPerSecond"`
+	// PairMessages is the amount of messages a business phone number can send to the same user within PairWindowSeconds
+	PairMessages      int `json:"pairMessages"`
+	PairWindowSeconds int `json:"pairWindowSeconds"`
+	// BusinessUseCaseCalls is the amount of calls that can be made for a WABA within BusinessUseCaseWindowSeconds
+	BusinessUseCaseCalls         int `json:"businessUseCaseCalls"`
+	BusinessUseCaseWindowSeconds int `json:"businessUseCaseWindowSeconds"`
+}
+
+var Limits = state.State[Config]{}
+
+// window keeps track of events within a sliding time window
+type window struct {
+	lock   sync.Mutex
+	events map[string][]time.Time
+}
+
+var (
+	throughputWindow      = window{events: map[string][]time.Time{}}
+	pairWindow            = window{events: map[string][]time.Time{}}
+	businessUseCaseWindow = window{events: map[string][]time.Time{}}
+)
+
+func (w *window) reset() {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.events = map[string][]time.Time{}
+}
+
+// Reset forgets all recorded events so all limits start over
+func Reset() {
+	throughputWindow.reset()
+	pairWindow.reset()
+	businessUseCaseWindow.reset()
+}
+
+// take records an event for key if there are less than limit events within duration.
+// It returns the amount of events within the duration and, when the limit is hit, when the oldest event leaves the window
+func (w *window) take(key string, limit int, duration time.Duration) (count int, retryAt *time.Time) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	now := time.Now()
+	events := []time.Time{}
+	for _, event := range w.events[key] {
+		if now.Sub(event) < duration {
+			events = append(events, event)
+		}
+	}
+
+	if len(events) >= limit {
+		w.events[key] = events
+		retry := events[0].Add(duration)
+		return len(events), &retry
+	}
+
+	w.events[key] = append(events, now)
+	return len(events) + 1, nil
+}
+
+func windowDuration(seconds int) time.Duration {
+	if seconds <= 0 {
+		return time.Second
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// CheckBusinessUseCase counts a call for the WABA and sets the X-Business-Use-Case-Usage header
+func CheckBusinessUseCase(c *fiber.Ctx, wabaID string) error {
+	limits := Limits.Get()
+
+	callCount := 0
+	regainAccessMinutes := 0
+	limitHit := false
+	if limits.BusinessUseCaseCalls > 0 {
+		count, retryAt := businessUseCaseWindow.take(wabaID, limits.BusinessUseCaseCalls, windowDuration(limits.BusinessUseCaseWindowSeconds))
+		callCount = count * 100 / limits.BusinessUseCaseCalls
+		if retryAt != nil {
+			limitHit = true
+			regainAccessMinutes = int(time.Until(*retryAt).Minutes()) + 1
+		}
+	}
+
+	usage, _ := json.Marshal(map[string]any{
+		wabaID: []map[string]any{{
+			"type":                            "whatsapp_business_messaging",
+			"call_count":                      callCount,
+			"total_cputime":                   callCount / 2,
+			"total_time":                      callCount / 2,
+			"estimated_time_to_regain_access": regainAccessMinutes,
+		}},
+	})
+	c.Set("X-Business-Use-Case-Usage", string(usage))
+
+	if limitHit {
+		return graph.KnownError(80007)
+	}
+	return nil
+}
+
+// CheckThroughput returns an error if the business phone number sends more messages per second than allowed
+func CheckThroughput(phoneNumberID string) error {
+	limits := Limits.Get()
+	if limits.MessagesPerSecond <= 0 {
+		return nil
+	}
+
+	_, retryAt := throughputWindow.take(phoneNumberID, limits.MessagesPerSecond, time.Second)
+	if retryAt != nil {
+		return graph.KnownError(130429)
+	}
+	return nil
+}
+
+// CheckPair returns an error if the business phone number sends to many messages to the same user
+func CheckPair(phoneNumberID string, to string) error {
+	limits := Limits.Get()
+	if limits.PairMessages <= 0 {
+		return nil
+	}
+
+	_, retryAt := pairWindow.take(phoneNumberID+"/"+to, limits.PairMessages, windowDuration(limits.PairWindowSeconds))
+	if retryAt != nil {
+		return graph.KnownError(131056)
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package traffic
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// HAR is a HTTP Archive (version 1.2) as used by browser devtools
+type HAR struct {
+	Log HARLog `json:"log"`
+}
+
+type HARLog struct {
+	Version string     `json:"version"`
+	Creator HARCreator `json:"creator"`
+	Entries []HAREntry `json:"entries"`
+}
+
+type HARCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type HAREntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         HARRequest  `json:"request"`
+	Response        HARResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         HARTimings  `json:"timings"`
+	Comment         string      `json:"comment,omitempty"`
+}
+
+type HARRequest struct {
+	Method      string       `json:"method"`
+	URL         string       `json:"url"`
+	HTTPVersion string       `json:"httpVersion"`
+	Cookies     []struct{}   `json:"cookies"`
+	Headers     []Header     `json:"headers"`
+	QueryString []Header     `json:"queryString"`
+	PostData    *HARPostData `json:"postData,omitempty"`
+	HeadersSize int          `json:"headersSize"`
+	BodySize    int          `json:"bodySize"`
+}
+
+type HARPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type HARResponse struct {
+	Status      int        `json:"status"`
+	StatusText  string     `json:"statusText"`
+	HTTPVersion string     `json:"httpVersion"`
+	Cookies     []struct{} `json:"cookies"`
+	Headers     []Header   `json:"headers"`
+	Content     HARContent `json:"content"`
+	RedirectURL string     `json:"redirectURL"`
+	HeadersSize int        `json:"headersSize"`
+	BodySize    int        `json:"bodySize"`
+}
+
+type HARContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+type HARTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// ExportHAR returns the recorded entries as HTTP Archive
+func ExportHAR(redact bool) HAR {
+	harEntries := []HAREntry{}
+	for _, entry := range List(redact) {
+		harEntries = append(harEntries, harEntry(entry))
+	}
+
+	return HAR{Log: HARLog{
+		Version: "1.2",
+		Creator: HARCreator{Name: "whatsapp-dev", Version: "1.0"},
+		Entries: harEntries,
+	}}
+}
+
+func harEntry(entry Entry) HAREntry {
+	request := HARRequest{
+		Method:      entry.Method,
+		URL:         entry.URL,
+		HTTPVersion: "HTTP/1.1",
+		Cookies:     []struct{}{},
+		Headers:     entry.RequestHeaders,
+		QueryString: []Header{},
+		HeadersSize: -1,
+		BodySize:    len(entry.RequestBody),
+	}
+	requestURL, err := url.Parse(entry.URL)
+	if err == nil {
+		for name, values := range requestURL.Query() {
+			for _, value := range values {
+				request.QueryString = append(request.QueryString, Header{Name: name, Value: value})
+			}
+		}
+	}
+	if entry.RequestBody != "" {
+		request.PostData = &HARPostData{
+			MimeType: headerValue(entry.RequestHeaders, "Content-Type"),
+			Text:     entry.RequestBody,
+		}
+	}
+
+	content := HARContent{
+		Size:     len(entry.ResponseBody),
+		MimeType: headerValue(entry.ResponseHeaders, "Content-Type"),
+		Text:     entry.ResponseBody,
+	}
+	if !utf8.ValidString(entry.ResponseBody) {
+		content.Text = base64.StdEncoding.EncodeToString([]byte(entry.ResponseBody))
+		content.Encoding = "base64"
+	}
+
+	comments := []string{string(entry.Source)}
+	if entry.Error != "" {
+		comments = append(comments, "error: "+entry.Error)
+	}
+	if entry.Truncated {
+		comments = append(comments, "body truncated")
+	}
+
+	return HAREntry{
+		StartedDateTime: entry.StartedAt.Format(time.RFC3339Nano),
+		Time:            entry.DurationMs,
+		Request:         request,
+		Response: HARResponse{
+			Status:      entry.Status,
+			StatusText:  http.StatusText(entry.Status),
+			HTTPVersion: "HTTP/1.1",
+			Cookies:     []struct{}{},
+			Headers:     entry.ResponseHeaders,
+			Content:     content,
+			RedirectURL: "",
+			HeadersSize: -1,
+			BodySize:    len(entry.ResponseBody),
+		},
+		Timings: HARTimings{Wait: entry.DurationMs},
+		Comment: strings.Join(comments, ", "),
+	}
+}
+
+func headerValue(headers []Header, name string) string {
+	for _, header := range headers {
+		if strings.EqualFold(header.Name, name) {
+			return header.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,271 @@
+package traffic
+
+import (
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// Capacity is the amount of entries kept, when full the oldest entry is dropped
+const Capacity = 1000
+
+// maxBodySize is the max size of recorded bodies, longer bodies are truncated
+const maxBodySize = 1 << 20
+
+// Source tells where an entry comes from
+type Source string
+
+const (
+	// SourceGraph are requests to the mocked graph api
+	SourceGraph Source = "graph"
+	// SourceWebhook are webhook calls made by whatsapp-dev
+	SourceWebhook Source = "webhook"
+)
+
+// InternalRequestKey is set as user value on requests dispatched within the server (like the requests of a batch) so they are not recorded
+const InternalRequestKey = "trafficInternalRequest"
+
+type Header struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// Entry is a recorded request and its response
+type Entry struct {
+	ID             uint      `json:"id"`
+	Source         Source    `json:"source"`
+	StartedAt      time.Time `json:"startedAt"`
+	DurationMs     float64   `json:"durationMs"`
+	Method         string    `json:"method"`
+	URL            string    `json:"url"`
+	RequestHeaders []Header  `json:"requestHeaders"`
+	RequestBody    string    `json:"requestBody"`
+	// Status is 0 if no response was received, see Error
+	Status          int      `json:"status"`
+	ResponseHeaders []Header `json:"responseHeaders"`
+	ResponseBody    string   `json:"responseBody"`
+	// Error is the reason no response was received
+	Error string `json:"error"`
+	// Truncated is true if the request or response body was longer than the max body size
+	Truncated bool `json:"truncated"`
+}
+
+var (
+	lock    sync.Mutex
+	nextID  uint = 1
+	entries      = []Entry{}
+)
+
+// Record adds an entry, the oldest entry is dropped if there are more than Capacity entries
+func Record(entry Entry) {
+	entry.RequestBody, entry.Truncated = truncate(entry.RequestBody, entry.Truncated)
+	entry.ResponseBody, entry.Truncated = truncate(entry.ResponseBody, entry.Truncated)
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	entry.ID = nextID
+	nextID++
+	entries = append(entries, entry)
+	if len(entries) > Capacity {
+		entries = append([]Entry{}, entries[len(entries)-Capacity:]...)
+	}
+}
+
+func truncate(body string, truncated bool) (string, bool) {
+	if len(body) > maxBodySize {
+		return body[:maxBodySize], true
+	}
+	return body, truncated
+}
+
+// List returns all recorded entries, oldest first.
+// If redact is true access tokens are replaced by REDACTED.
+func List(redact bool) []Entry {
+	lock.Lock()
+	list := append([]Entry{}, entries...)
+	lock.Unlock()
+
+	if redact {
+		for idx, entry := range list {
+			list[idx] = redacted(entry)
+		}
+	}
+	return list
+}
+
+// Clear removes all recorded entries
+func Clear() {
+	lock.Lock()
+	defer lock.Unlock()
+
+	entries = []Entry{}
+}
+
+// Middleware records the requests to the mocked graph api
+func Middleware(c *fiber.Ctx) error {
+	if !isGraphRequest(c) {
+		return c.Next()
+	}
+
+	startedAt := time.Now()
+	// Fiber strings point into buffers that are reused after the request, so copy them
+	entry := Entry{
+		Source:         SourceGraph,
+		StartedAt:      startedAt,
+		Method:         utils.CopyString(c.Method()),
+		URL:            utils.CopyString(c.BaseURL() + c.OriginalURL()),
+		RequestHeaders: []Header{},
+		RequestBody:    string(c.Body()),
+	}
+	c.Request().Header.VisitAll(func(key, value []byte) {
+		entry.RequestHeaders = append(entry.RequestHeaders, Header{Name: string(key), Value: string(value)})
+	})
+
+	err := c.Next()
+	if err != nil {
+		// Write the error response like fiber would so it can be recorded
+		err = c.App().Config().ErrorHandler(c, err)
+		if err != nil {
+			return err
+		}
+	}
+
+	entry.DurationMs = float64(time.Since(startedAt).Microseconds()) / 1000
+	entry.Status = c.Response().StatusCode()
+	entry.ResponseBody = string(c.Response().Body())
+	entry.ResponseHeaders = []Header{}
+	c.Response().Header.VisitAll(func(key, value []byte) {
+		entry.ResponseHeaders = append(entry.ResponseHeaders, Header{Name: string(key), Value: string(value)})
+	})
+	Record(entry)
+
+	return nil
+}
+
+var graphPathRegex = regexp.MustCompile(`^/(v\d+(\.\d+)?(/|$)|debug_token$|media/)`)
+
+// isGraphRequest returns true for requests to the mocked graph api, requests to the admin api and ui are not recorded
+func isGraphRequest(c *fiber.Ctx) bool {
+	if internal, _ := c.Context().UserValue(InternalRequestKey).(bool); internal {
+		return false
+	}
+	if c.Path() == "/" {
+		// Batch requests
+		return c.Method() == fiber.MethodPost
+	}
+	return graphPathRegex.MatchString(c.Path())
+}
+
+// RecordWebhook records a webhook call, res is nil if no response was received
+func RecordWebhook(req *http.Request, requestBody []byte, startedAt time.Time, res *http.Response, responseBody []byte, err error) {
+	entry := Entry{
+		Source:         SourceWebhook,
+		StartedAt:      startedAt,
+		DurationMs:     float64(time.Since(startedAt).Microseconds()) / 1000,
+		Method:         req.Method,
+		URL:            req.URL.String(),
+		RequestHeaders: httpHeaders(req.Header),
+		RequestBody:    string(requestBody),
+	}
+	if res != nil {
+		entry.Status = res.StatusCode
+		entry.ResponseHeaders = httpHeaders(res.Header)
+		entry.ResponseBody = string(responseBody)
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	Record(entry)
+}
+
+func httpHeaders(header http.Header) []Header {
+	headers := []Header{}
+	for name, values := range header {
+		for _, value := range values {
+			headers = append(headers, Header{Name: name, Value: value})
+		}
+	}
+	return headers
+}
+
+const redactedValue = "REDACTED"
+
+// tokenParams are the parameters containing tokens, input_token is the inspected token of GET /debug_token
+var tokenParams = []string{"access_token", "input_token"}
+
+// redacted returns the entry with the tokens in the authorization header, url and form body redacted
+func redacted(entry Entry) Entry {
+	entry.RequestHeaders = append([]Header{}, entry.RequestHeaders...)
+	contentType := ""
+	for idx, header := range entry.RequestHeaders {
+		if strings.EqualFold(header.Name, fiber.HeaderContentType) {
+			contentType = header.Value
+		}
+		if !strings.EqualFold(header.Name, fiber.HeaderAuthorization) {
+			continue
+		}
+		scheme, _, found := strings.Cut(header.Value, " ")
+		if found {
+			entry.RequestHeaders[idx].Value = scheme + " " + redactedValue
+		} else {
+			entry.RequestHeaders[idx].Value = redactedValue
+		}
+	}
+
+	requestURL, err := url.Parse(entry.URL)
+	if err == nil {
+		query := requestURL.Query()
+		if redactValues(query) {
+			requestURL.RawQuery = query.Encode()
+			entry.URL = requestURL.String()
+		}
+	}
+
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return entry
+	}
+	switch mediaType {
+	case fiber.MIMEApplicationForm:
+		form, err := url.ParseQuery(entry.RequestBody)
+		if err == nil && redactValues(form) {
+			entry.RequestBody = form.Encode()
+		}
+	case fiber.MIMEMultipartForm:
+		if params["boundary"] != "" {
+			entry.RequestBody = redactMultipart(entry.RequestBody, params["boundary"])
+		}
+	}
+
+	return entry
+}
+
+// redactValues redacts the token parameters of values and returns true if one was found
+func redactValues(values url.Values) bool {
+	found := false
+	for _, param := range tokenParams {
+		if values.Has(param) {
+			values.Set(param, redactedValue)
+			found = true
+		}
+	}
+	return found
+}
+
+// redactMultipart redacts the values of the token parameters in a multipart body.
+// The parts are replaced in place so file parts are kept as is, also if the body is truncated.
+func redactMultipart(body string, boundary string) string {
+	for _, param := range tokenParams {
+		partRegex := regexp.MustCompile(`(?is)(content-disposition:[^\r\n]*[; ]name="?` + param + `"?(?:;[^\r\n]*)?\r\n(?:[^\r\n]+\r\n)*\r\n).*?(\r\n--` + regexp.QuoteMeta(boundary) + `)`)
+		body = partRegex.ReplaceAllString(body, "${1}"+redactedValue+"${2}")
+	}
+	return body
+}
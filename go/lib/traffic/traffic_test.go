@@ -0,0 +1,79 @@
+package traffic
+
+import (
+	"bytes"
+	"mime/multipart"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestRedactedMultipartAccessToken(t *testing.T) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	writer.WriteField("messaging_product", "whatsapp")
+	writer.WriteField("access_token", "EAAsecret")
+	file, err := writer.CreateFormFile("file", "access_token.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.Write([]byte("\x89PNG\r\n\x1a\nEAAsecret"))
+	writer.Close()
+
+	entry := redacted(Entry{
+		URL:            "http://localhost/v19.0/1234/media",
+		RequestHeaders: []Header{{Name: fiber.HeaderContentType, Value: writer.FormDataContentType()}},
+		RequestBody:    body.String(),
+	})
+
+	if strings.Count(entry.RequestBody, "EAAsecret") != 1 {
+		t.Fatalf("expected only the access token to be redacted, got %q", entry.RequestBody)
+	}
+	if !strings.Contains(entry.RequestBody, "name=\"access_token\"\r\n\r\nREDACTED\r\n--"+writer.Boundary()) {
+		t.Fatalf("expected the access token to be redacted, got %q", entry.RequestBody)
+	}
+	if !strings.Contains(entry.RequestBody, "\x89PNG\r\n\x1a\nEAAsecret") || !strings.Contains(entry.RequestBody, "whatsapp") {
+		t.Fatalf("expected the other parts to be kept as is, got %q", entry.RequestBody)
+	}
+
+	form, err := multipart.NewReader(strings.NewReader(entry.RequestBody), writer.Boundary()).ReadForm(1 << 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if form.Value["access_token"][0] != redactedValue {
+		t.Fatalf("expected the parsed access token to be %s, got %q", redactedValue, form.Value["access_token"][0])
+	}
+}
+
+func TestRedactedDebugTokenInputToken(t *testing.T) {
+	entry := redacted(Entry{
+		URL:            "http://localhost/v19.0/debug_token?input_token=EAAinspected&access_token=1234%7Csecret",
+		RequestHeaders: []Header{},
+	})
+
+	if strings.Contains(entry.URL, "EAAinspected") || strings.Contains(entry.URL, "secret") {
+		t.Fatalf("expected the tokens to be redacted, got %s", entry.URL)
+	}
+	if !strings.Contains(entry.URL, "input_token=REDACTED") || !strings.Contains(entry.URL, "access_token=REDACTED") {
+		t.Fatalf("expected the token parameters to be kept, got %s", entry.URL)
+	}
+}
+
+func TestRedactedFormAndHeader(t *testing.T) {
+	entry := redacted(Entry{
+		URL: "http://localhost/v19.0/1234/messages",
+		RequestHeaders: []Header{
+			{Name: fiber.HeaderContentType, Value: fiber.MIMEApplicationForm + "; charset=utf-8"},
+			{Name: fiber.HeaderAuthorization, Value: "Bearer EAAsecret"},
+		},
+		RequestBody: "access_token=EAAsecret&to=31612345678",
+	})
+
+	if entry.RequestHeaders[1].Value != "Bearer REDACTED" {
+		t.Fatalf("expected the authorization header to be redacted, got %q", entry.RequestHeaders[1].Value)
+	}
+	if entry.RequestBody != "access_token=REDACTED&to=31612345678" {
+		t.Fatalf("expected the form access token to be redacted, got %q", entry.RequestBody)
+	}
+}
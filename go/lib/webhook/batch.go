@@ -0,0 +1,144 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// event is a single message or status that should be delivered to the webhook, either message or status is set
+type event struct {
+	target        target
+	entryID       string
+	metadata      M
+	phoneNumberID string
+	waID          string
+	contact       M
+	message       M
+	status        M
+}
+
+var (
+	batchLock   sync.Mutex
+	batchTimer  *time.Timer
+	batchEvents []event
+)
+
+// enqueueEvent adds an event to the current batch.
+// The first event of a batch schedules the batch to be send after the batch window has passed
+func enqueueEvent(e event, window time.Duration) {
+	batchLock.Lock()
+	defer batchLock.Unlock()
+
+	if batchTimer == nil {
+		batchTimer = time.AfterFunc(window, flushBatch)
+	}
+	batchEvents = append(batchEvents, e)
+}
+
+func flushBatch() {
+	batchLock.Lock()
+	events := batchEvents
+	batchEvents = nil
+	batchTimer = nil
+	batchLock.Unlock()
+
+	// Events created before and after the webhook settings changed are send to their own target
+	for len(events) > 0 {
+		to := events[0].target
+		toTarget := []event{}
+		rest := []event{}
+		for _, e := range events {
+			if e.target == to {
+				toTarget = append(toTarget, e)
+			} else {
+				rest = append(rest, e)
+			}
+		}
+		events = rest
+
+		if !to.active() {
+			continue
+		}
+
+		payload, err := json.Marshal(eventsPayload(toTarget))
+		if err != nil {
+			fmt.Println("failed to create webhook batch payload, error:", err.Error())
+			continue
+		}
+
+		go func() {
+			err := makeWebhookRequestWithRandomForcedRetries(to, payload)
+			if err != nil {
+				fmt.Println("failed to call webhook, error response:", err.Error())
+			}
+		}()
+	}
+}
+
+// eventsPayload creates the webhook payload for one or more events.
+// Messages of the same contact are coalesced into one change with multiple messages,
+// statuses of the same phone number are coalesced into one change with multiple statuses
+// and every change gets its own entry.
+// This mimics the shapes the real api sends when it batches events together.
+func eventsPayload(events []event) M {
+	type group struct {
+		entryID  string
+		metadata M
+		contact  M
+		messages []M
+		statuses []M
+	}
+
+	groups := []*group{}
+	groupsByKey := map[string]*group{}
+	for _, e := range events {
+		key := e.phoneNumberID + "/" + e.waID
+		if e.status != nil {
+			key = e.phoneNumberID + "/statuses"
+		}
+		g, ok := groupsByKey[key]
+		if !ok {
+			g = &group{
+				entryID:  e.entryID,
+				metadata: e.metadata,
+				contact:  e.contact,
+			}
+			groupsByKey[key] = g
+			groups = append(groups, g)
+		}
+		if e.status != nil {
+			g.statuses = append(g.statuses, e.status)
+		} else {
+			g.messages = append(g.messages, e.message)
+		}
+	}
+
+	entries := []M{}
+	for _, g := range groups {
+		value := M{
+			"messaging_product": "whatsapp",
+			"metadata":          g.metadata,
+		}
+		if g.statuses != nil {
+			value["statuses"] = g.statuses
+		} else {
+			value["contacts"] = []M{g.contact}
+			value["messages"] = g.messages
+		}
+
+		entries = append(entries, M{
+			"id": g.entryID,
+			"changes": []M{{
+				"value": value,
+				"field": "messages",
+			}},
+		})
+	}
+
+	return M{
+		"object": "whatsapp_business_account",
+		"entry":  entries,
+	}
+}
@@ -13,9 +13,11 @@ import (
 	"net/http"
 	"net/url"
 	"strconv"
+	"sync"
 	"time"
 
 	. "github.com/mjarkk/whatsapp-dev/go/db"
+	"github.com/mjarkk/whatsapp-dev/go/lib/traffic"
 	"github.com/mjarkk/whatsapp-dev/go/models"
 	"github.com/mjarkk/whatsapp-dev/go/state"
 	"github.com/mjarkk/whatsapp-dev/go/utils/random"
@@ -27,9 +29,56 @@ var httpClient = http.Client{
 
 type M map[string]any
 
-func createSignatures(body []byte) map[string]string {
-	appSecret := state.AppSecret.Get()
+// target is the webhook an event is delivered to, it is captured when the event is created so events created before
+// the webhook settings changed are never delivered to the new webhook or signed with the new app secret
+type target struct {
+	url        string
+	appSecret  string
+	generation uint
+}
+
+var (
+	generationLock sync.Mutex
+	generation     uint
+)
+
+// currentTarget returns the target of events created now
+func currentTarget() target {
+	generationLock.Lock()
+	defer generationLock.Unlock()
+
+	return target{
+		url:        state.WebhookURL.Get(),
+		appSecret:  state.AppSecret.Get(),
+		generation: generation,
+	}
+}
 
+// active returns false if the pending deliveries to the target were cancelled
+func (t target) active() bool {
+	generationLock.Lock()
+	defer generationLock.Unlock()
+
+	return t.generation == generation
+}
+
+// CancelPending drops the events waiting in the current batch and stops the pending (forced) retries of earlier webhook calls.
+// Webhook calls that are in flight are finished.
+func CancelPending() {
+	generationLock.Lock()
+	generation++
+	generationLock.Unlock()
+
+	batchLock.Lock()
+	if batchTimer != nil {
+		batchTimer.Stop()
+		batchTimer = nil
+	}
+	batchEvents = nil
+	batchLock.Unlock()
+}
+
+func createSignatures(appSecret string, body []byte) map[string]string {
 	sha1Hmac := hmac.New(sha1.New, []byte(appSecret))
 	sha1Hmac.Write(body)
 	sha1Signature := "sha1=" + hex.EncodeToString(sha1Hmac.Sum(nil))
@@ -64,13 +113,16 @@ func Validate() error {
 		return err
 	}
 
+	startedAt := time.Now()
 	resp, err := httpClient.Do(req)
 	if err != nil {
+		traffic.RecordWebhook(req, nil, startedAt, nil, nil, err)
 		return err
 	}
 	defer resp.Body.Close()
 
 	respBody, err := io.ReadAll(resp.Body)
+	traffic.RecordWebhook(req, nil, startedAt, resp, respBody, err)
 	if err != nil {
 		return err
 	}
@@ -94,8 +146,19 @@ func NotivyMessage(message models.Message, awaitResponse bool) error {
 		return err
 	}
 
+	businessNumber := models.BusinessPhoneNumber{}
+	err = DB.Model(models.BusinessPhoneNumber{}).First(&businessNumber, conversation.BusinessPhoneNumberID).Error
+	if err != nil {
+		return err
+	}
+
+	businessAccount, err := businessNumber.BusinessAccount()
+	if err != nil {
+		return err
+	}
+
 	timestamp := strconv.Itoa(int(message.Timestamp))
-	from := conversation.PhoneNumber
+	from := conversation.ContactWaID()
 	bodyMessage := M{
 		"from":      from,
 		"id":        message.WhatsappID,
@@ -116,38 +179,91 @@ func NotivyMessage(message models.Message, awaitResponse bool) error {
 		}
 	}
 
-	data := M{
-		"object": "whatsapp_business_account",
-		"entry": []M{{
-			"id": strconv.Itoa(int(message.ID)),
-			"changes": []M{{
-				"value": M{
-					"messaging_product": "whatsapp",
-					"metadata": M{
-						"display_phone_number": state.PhoneNumber.Get(),
-						"phone_number_id":      state.PhoneNumberID.Get(),
-					},
-					"contacts": []M{{
-						// FIXME Add a custom contact name to the conversation
-						// FIXME I think the production whatsapp api version does not always send the contact name
-						"profile": M{"name": "Jhon doe"},
-						"wa_id":   conversation.PhoneNumberId,
-					}},
-					"messages": []M{bodyMessage},
-				},
-				"field": "messages",
-			}},
-		}},
-	}
-
-	payload, err := json.Marshal(data)
+	contact := M{"wa_id": from}
+	if !conversation.HideProfileName {
+		contact["profile"] = M{"name": conversation.ContactProfileName()}
+	}
+
+	webhookEvent := event{
+		target:  currentTarget(),
+		entryID: businessAccount.WabaID,
+		metadata: M{
+			"display_phone_number": businessNumber.PhoneNumber,
+			"phone_number_id":      businessNumber.PhoneNumberID,
+		},
+		phoneNumberID: businessNumber.PhoneNumberID,
+		waID:          from,
+		contact:       contact,
+		message:       bodyMessage,
+	}
+
+	return deliver(webhookEvent, awaitResponse)
+}
+
+// Message statuses send to the webhook
+const (
+	StatusSent      = "sent"
+	StatusDelivered = "delivered"
+)
+
+// NotivyStatus notifies the webhook about a status change of a message sent by the business, status webhooks are only send if enabled
+func NotivyStatus(message models.Message, status string) error {
+	if !state.WebhookStatuses.Get() {
+		return nil
+	}
+
+	conversation := models.Conversation{}
+	err := DB.Model(models.Conversation{}).Find(&conversation, message.ConversationID).Error
+	if err != nil {
+		return err
+	}
+
+	businessNumber := models.BusinessPhoneNumber{}
+	err = DB.Model(models.BusinessPhoneNumber{}).First(&businessNumber, conversation.BusinessPhoneNumberID).Error
+	if err != nil {
+		return err
+	}
+
+	businessAccount, err := businessNumber.BusinessAccount()
+	if err != nil {
+		return err
+	}
+
+	webhookEvent := event{
+		target:  currentTarget(),
+		entryID: businessAccount.WabaID,
+		metadata: M{
+			"display_phone_number": businessNumber.PhoneNumber,
+			"phone_number_id":      businessNumber.PhoneNumberID,
+		},
+		phoneNumberID: businessNumber.PhoneNumberID,
+		status: M{
+			"id":           message.WhatsappID,
+			"status":       status,
+			"timestamp":    strconv.FormatInt(time.Now().Unix(), 10),
+			"recipient_id": conversation.ContactWaID(),
+		},
+	}
+
+	return deliver(webhookEvent, false)
+}
+
+// deliver sends the event to the webhook, events are added to the current batch if batching is enabled
+func deliver(webhookEvent event, awaitResponse bool) error {
+	batchWindow := state.WebhookBatchWindow.Get()
+	if batchWindow > 0 && !awaitResponse {
+		enqueueEvent(webhookEvent, batchWindow)
+		return nil
+	}
+
+	payload, err := json.Marshal(eventsPayload([]event{webhookEvent}))
 	if err != nil {
 		return err
 	}
 
 	if !awaitResponse {
 		go func() {
-			err := makeWebhookRequestWithRandomForcedRetries(payload)
+			err := makeWebhookRequestWithRandomForcedRetries(webhookEvent.target, payload)
 			if err != nil {
 				fmt.Println("failed to call webhook, error response:", err.Error())
 			}
@@ -155,14 +271,14 @@ func NotivyMessage(message models.Message, awaitResponse bool) error {
 		return nil
 	}
 
-	return makeWebhookRequestWithRandomForcedRetries(payload)
+	return makeWebhookRequestWithRandomForcedRetries(webhookEvent.target, payload)
 }
 
-func makeWebhookRequestWithRandomForcedRetries(payload []byte) error {
+func makeWebhookRequestWithRandomForcedRetries(to target, payload []byte) error {
 	randomSleepDuration := rand.Intn(int(time.Millisecond * 1500))
 	time.Sleep(time.Duration(randomSleepDuration))
 
-	err := makeWebhookRequest(payload)
+	err := makeWebhookRequest(to, payload)
 	if err != nil {
 		return err
 	}
@@ -176,7 +292,7 @@ func makeWebhookRequestWithRandomForcedRetries(payload []byte) error {
 	randomSleepDuration = rand.Intn(int(time.Second * 10))
 	time.Sleep(time.Duration(randomSleepDuration))
 
-	err = makeWebhookRequest(payload)
+	err = makeWebhookRequest(to, payload)
 	if err != nil {
 		return err
 	}
@@ -190,10 +306,11 @@ func makeWebhookRequestWithRandomForcedRetries(payload []byte) error {
 	randomSleepDuration = rand.Intn(int(time.Minute))
 	time.Sleep(time.Duration(randomSleepDuration))
 
-	return makeWebhookRequest(payload)
+	return makeWebhookRequest(to, payload)
 }
 
-func makeWebhookRequest(payload []byte) error {
+// makeWebhookRequest calls the webhook and retries failed calls, nothing is send once the pending deliveries to the target are cancelled
+func makeWebhookRequest(to target, payload []byte) error {
 	attempt := 0
 	var lastErr error
 
@@ -212,15 +329,18 @@ outer:
 		default:
 			break outer
 		}
+		if !to.active() {
+			return nil
+		}
 
 		body := bytes.NewBuffer(payload)
-		req, err := http.NewRequest("POST", state.WebhookURL.Get(), body)
+		req, err := http.NewRequest("POST", to.url, body)
 		if err != nil {
 			lastErr = err
 			continue
 		}
 
-		signatures := createSignatures(payload)
+		signatures := createSignatures(to.appSecret, payload)
 		for key, value := range signatures {
 			req.Header.Add(key, value)
 		}
@@ -233,12 +353,16 @@ outer:
 			fmt.Println("retrying webhook")
 		}
 
+		startedAt := time.Now()
 		resp, err := httpClient.Do(req)
 		if err != nil {
+			traffic.RecordWebhook(req, payload, startedAt, nil, nil, err)
 			lastErr = err
 			continue
 		}
+		respBody, err := io.ReadAll(resp.Body)
 		resp.Body.Close()
+		traffic.RecordWebhook(req, payload, startedAt, resp, respBody, err)
 
 		if resp.StatusCode < 400 {
 			return nil
@@ -2,10 +2,40 @@ package src
 
 import (
 	"github.com/gofiber/fiber/v2"
+	"github.com/mjarkk/whatsapp-dev/go/controller/batch"
 	"github.com/mjarkk/whatsapp-dev/go/controller/messages"
+	"github.com/mjarkk/whatsapp-dev/go/controller/nodes"
+	"github.com/mjarkk/whatsapp-dev/go/controller/phonenumbers"
+	"github.com/mjarkk/whatsapp-dev/go/controller/tokens"
+	"github.com/mjarkk/whatsapp-dev/go/controller/uploads"
+	"github.com/mjarkk/whatsapp-dev/go/lib/graph"
+	"github.com/mjarkk/whatsapp-dev/go/models"
 )
 
 func mockRoutes(r fiber.Router) {
-	version := r.Group("/v:version")
-	version.Post("/:phoneNumberId/messages", messages.Create)
+	authenticated := graph.RequireScope("")
+	messaging := graph.RequireScope(models.ScopeBusinessMessaging)
+	management := graph.RequireScope(models.ScopeBusinessManagement)
+
+	r.Get("/profile_pictures/:sessionId", uploads.File)
+	r.Get("/media/:mediaId", graph.ErrorMiddleware, authenticated, uploads.MediaFile)
+	r.Get("/debug_token", graph.ErrorMiddleware, tokens.Debug)
+	r.Post("/", graph.ErrorMiddleware, authenticated, batch.Handle)
+
+	version := r.Group("/v:version", graph.ErrorMiddleware, graph.VersionMiddleware)
+	version.Get("/debug_token", tokens.Debug)
+	version.Post("/", authenticated, batch.Handle)
+	version.Get("/upload\\::sessionId", authenticated, uploads.Status)
+	version.Post("/upload\\::sessionId", authenticated, uploads.Upload)
+	version.Post("/:appId/uploads", authenticated, uploads.CreateSession)
+	version.Post("/:phoneNumberId/messages", messaging, messages.Create)
+	version.Post("/:phoneNumberId/register", management, phonenumbers.Register)
+	version.Post("/:phoneNumberId/deregister", management, phonenumbers.Deregister)
+	version.Post("/:phoneNumberId/request_code", management, phonenumbers.RequestCode)
+	version.Post("/:phoneNumberId/verify_code", management, phonenumbers.VerifyCode)
+	version.Get("/:phoneNumberId/whatsapp_business_profile", management, phonenumbers.GetBusinessProfile)
+	version.Post("/:phoneNumberId/whatsapp_business_profile", management, phonenumbers.UpdateBusinessProfile)
+	version.Post("/:phoneNumberId/media", messaging, uploads.CreateMedia)
+	version.Get("/:id", authenticated, nodes.Get)
+	version.Post("/:phoneNumberId", management, phonenumbers.SetTwoStepPin)
 }
@@ -0,0 +1,124 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/mjarkk/whatsapp-dev/go/db"
+	"gorm.io/gorm"
+)
+
+// Scopes that can be granted to an access token
+const (
+	ScopeBusinessMessaging  = "whatsapp_business_messaging"
+	ScopeBusinessManagement = "whatsapp_business_management"
+)
+
+// AllScopes are all the scopes an access token can have
+var AllScopes = []string{ScopeBusinessMessaging, ScopeBusinessManagement}
+
+type AccessTokenType string
+
+const (
+	AccessTokenTypeUser       AccessTokenType = "USER"
+	AccessTokenTypeSystemUser AccessTokenType = "SYSTEM_USER"
+)
+
+type RevokeReason string
+
+const (
+	// RevokeReasonInvalidated mimics a session that was invalidated, for example because the user logged out
+	RevokeReasonInvalidated RevokeReason = "invalidated"
+	// RevokeReasonPasswordChanged mimics a user that changed their password
+	RevokeReasonPasswordChanged RevokeReason = "password_changed"
+	// RevokeReasonAppRemoved mimics a user that removed the app
+	RevokeReasonAppRemoved RevokeReason = "app_removed"
+)
+
+// AccessToken is a graph api access token
+type AccessToken struct {
+	gorm.Model
+	Name         string          `json:"name"`
+	Token        string          `json:"token" gorm:"uniqueIndex"`
+	Type         AccessTokenType `json:"type"`
+	Scopes       []string        `json:"scopes" gorm:"serializer:json"`
+	ExpiresAt    *time.Time      `json:"expiresAt"`
+	RevokedAt    *time.Time      `json:"revokedAt"`
+	RevokeReason *RevokeReason   `json:"revokeReason"`
+}
+
+// DefaultAccessTokenName is the name of the access token defined by the startup options, other tokens cannot use this name
+const DefaultAccessTokenName = "Default"
+
+// EnsureDefaultAccessToken makes sure the graph token defined by the startup options exists.
+// This token never expires and has all scopes.
+// Default tokens of earlier runs with another graph token are removed so they stop working, like before access tokens were stored.
+func EnsureDefaultAccessToken(token string) error {
+	err := DB.Unscoped().Where("name = ? AND token != ?", DefaultAccessTokenName, token).Delete(&AccessToken{}).Error
+	if err != nil {
+		return err
+	}
+
+	// Tokens are unique including soft deleted ones, a Default token deleted by an earlier version is restored
+	defaultToken := AccessToken{}
+	err = DB.Unscoped().Where("token = ?", token).Limit(1).Find(&defaultToken).Error
+	if err != nil {
+		return err
+	}
+	if defaultToken.ID == 0 {
+		return DB.Create(&AccessToken{
+			Name:   DefaultAccessTokenName,
+			Token:  token,
+			Type:   AccessTokenTypeSystemUser,
+			Scopes: AllScopes,
+		}).Error
+	}
+	if defaultToken.DeletedAt.Valid {
+		return DB.Unscoped().Model(&defaultToken).Update("deleted_at", nil).Error
+	}
+	return nil
+}
+
+// FindAccessToken returns the access token with the value token
+func FindAccessToken(token string) (*AccessToken, error) {
+	accessToken := &AccessToken{}
+	err := DB.Where("token = ?", token).First(accessToken).Error
+	if err != nil {
+		return nil, err
+	}
+	return accessToken, nil
+}
+
+// IsExpired returns true if the token has an expiry date in the past
+func (t *AccessToken) IsExpired() bool {
+	return t.ExpiresAt != nil && t.ExpiresAt.Before(time.Now())
+}
+
+// IsValid returns true if the token can be used to make requests
+func (t *AccessToken) IsValid() bool {
+	return t.RevokedAt == nil && !t.IsExpired()
+}
+
+// HasScope returns true if the token was granted scope
+func (t *AccessToken) HasScope(scope string) bool {
+	for _, tokenScope := range t.Scopes {
+		if tokenScope == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateScopes returns an error if one of the scopes is unknown
+func ValidateScopes(scopes []string) error {
+outer:
+	for _, scope := range scopes {
+		for _, knownScope := range AllScopes {
+			if scope == knownScope {
+				continue outer
+			}
+		}
+		return errors.New("unknown scope " + scope)
+	}
+	return nil
+}
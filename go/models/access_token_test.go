@@ -0,0 +1,76 @@
+package models
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/mjarkk/whatsapp-dev/go/db"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// openDatabase opens the sqlite database at path like a (re)started instance does
+func openDatabase(t *testing.T, path string) {
+	t.Helper()
+
+	database, err := gorm.Open(sqlite.Open(path), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.DB = database
+	t.Cleanup(func() { db.CloseDatabase() })
+
+	err = db.DB.AutoMigrate(&AccessToken{})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEnsureDefaultAccessTokenAfterRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "db.sqlite")
+
+	openDatabase(t, path)
+	err := EnsureDefaultAccessToken("first-token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := FindAccessToken("first-token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Earlier versions allowed soft deleting the Default token
+	err = db.DB.Delete(token).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.CloseDatabase()
+
+	// Restart with the same graph token
+	openDatabase(t, path)
+	err = EnsureDefaultAccessToken("first-token")
+	if err != nil {
+		t.Fatalf("expected the restart to succeed, got %s", err.Error())
+	}
+	token, err = FindAccessToken("first-token")
+	if err != nil {
+		t.Fatalf("expected the Default token to be restored, got %s", err.Error())
+	}
+	if token.Name != DefaultAccessTokenName || !token.IsValid() {
+		t.Fatalf("expected a valid Default token, got %+v", token)
+	}
+
+	// Restart with another graph token
+	err = EnsureDefaultAccessToken("second-token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = FindAccessToken("first-token")
+	if err == nil {
+		t.Fatal("expected the Default token of the earlier graph token to be removed")
+	}
+	_, err = FindAccessToken("second-token")
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,203 @@
+package models
+
+import (
+	"errors"
+
+	. "github.com/mjarkk/whatsapp-dev/go/db"
+	"github.com/mjarkk/whatsapp-dev/go/state"
+	"gorm.io/gorm"
+)
+
+// BusinessAccount is a simulated WhatsApp Business Account (WABA)
+type BusinessAccount struct {
+	gorm.Model
+	WabaID       string                `json:"wabaId"`
+	Name         string                `json:"name"`
+	PhoneNumbers []BusinessPhoneNumber `json:"phoneNumbers"`
+}
+
+// BusinessPhoneNumber is a simulated business phone number that belongs to a WABA
+type BusinessPhoneNumber struct {
+	gorm.Model
+	BusinessAccountID uint   `json:"businessAccountId"`
+	PhoneNumber       string `json:"phoneNumber"`
+	// PhoneNumberID is the graph api id of the phone number
+	PhoneNumberID string `json:"phoneNumberId"`
+	VerifiedName  string `json:"verifiedName"`
+	QualityRating string `json:"qualityRating"`
+	// RegistrationStatus is REGISTERED if the number can be used to send messages
+	RegistrationStatus     RegistrationStatus     `json:"registrationStatus"`
+	CodeVerificationStatus CodeVerificationStatus `json:"codeVerificationStatus"`
+	// VerificationCode is the code send by the last request_code call
+	VerificationCode *string `json:"verificationCode"`
+	// TwoStepPin is the two-step verification pin set while registering the number
+	TwoStepPin *string          `json:"twoStepPin"`
+	Profile    *BusinessProfile `json:"profile"`
+	// RecipientMode decides to who messages can be send, see RecipientAllowed
+	RecipientMode RecipientMode `json:"recipientMode"`
+	// AllowedRecipients are the phone numbers messages can be send to in sandbox mode
+	AllowedRecipients []string `json:"allowedRecipients" gorm:"serializer:json"`
+}
+
+type RecipientMode string
+
+const (
+	// RecipientModeConversations allows sending templates to everyone but other messages only to contacts with a conversation.
+	// This is the default, numbers created before recipient modes existed have an empty mode that behaves the same.
+	RecipientModeConversations RecipientMode = "CONVERSATIONS"
+	// RecipientModeOpen allows sending messages to everyone like a production phone number
+	RecipientModeOpen RecipientMode = "OPEN"
+	// RecipientModeSandbox only allows sending messages to the allowed recipients like Meta's test phone number
+	RecipientModeSandbox RecipientMode = "SANDBOX"
+)
+
+// MaxSandboxRecipients is the maximum amount of allowed recipients of a phone number in sandbox mode
+const MaxSandboxRecipients = 5
+
+type RegistrationStatus string
+
+const (
+	RegistrationStatusRegistered   RegistrationStatus = "REGISTERED"
+	RegistrationStatusUnregistered RegistrationStatus = "UNREGISTERED"
+)
+
+type CodeVerificationStatus string
+
+const (
+	CodeVerificationStatusNotVerified CodeVerificationStatus = "NOT_VERIFIED"
+	CodeVerificationStatusVerified    CodeVerificationStatus = "VERIFIED"
+	CodeVerificationStatusExpired     CodeVerificationStatus = "EXPIRED"
+)
+
+// NewBusinessPhoneNumber returns a phone number that is verified and registered
+func NewBusinessPhoneNumber(businessAccountID uint, phoneNumber, phoneNumberID, verifiedName string) BusinessPhoneNumber {
+	return BusinessPhoneNumber{
+		BusinessAccountID:      businessAccountID,
+		PhoneNumber:            phoneNumber,
+		PhoneNumberID:          phoneNumberID,
+		VerifiedName:           verifiedName,
+		QualityRating:          "GREEN",
+		RegistrationStatus:     RegistrationStatusRegistered,
+		CodeVerificationStatus: CodeVerificationStatusVerified,
+		RecipientMode:          RecipientModeConversations,
+		AllowedRecipients:      []string{},
+	}
+}
+
+// EnsureDefaultBusiness makes sure the phone number and WABA defined by the startup options exist.
+// Conversations and templates created before multiple business numbers were supported are moved to them.
+func EnsureDefaultBusiness(wabaID, phoneNumber, phoneNumberID string) error {
+	account := BusinessAccount{}
+	err := DB.Where("waba_id = ?", wabaID).First(&account).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		account = BusinessAccount{
+			WabaID: wabaID,
+			Name:   "WhatsApp Dev",
+		}
+		err = DB.Create(&account).Error
+	}
+	if err != nil {
+		return err
+	}
+
+	number := BusinessPhoneNumber{}
+	err = DB.Where("phone_number_id = ?", phoneNumberID).First(&number).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		number = NewBusinessPhoneNumber(account.ID, phoneNumber, phoneNumberID, account.Name)
+		err = DB.Create(&number).Error
+	} else if err == nil && number.PhoneNumber != phoneNumber {
+		number.PhoneNumber = phoneNumber
+		err = DB.Save(&number).Error
+	}
+	if err != nil {
+		return err
+	}
+
+	// Phone numbers created before the registration flow was emulated are registered and verified
+	err = DB.Model(&BusinessPhoneNumber{}).Where("registration_status IS NULL OR registration_status = ''").Updates(map[string]any{
+		"quality_rating":           "GREEN",
+		"registration_status":      RegistrationStatusRegistered,
+		"code_verification_status": CodeVerificationStatusVerified,
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	err = DB.Model(&Conversation{}).Where("business_phone_number_id = 0 OR business_phone_number_id IS NULL").Update("business_phone_number_id", number.ID).Error
+	if err != nil {
+		return err
+	}
+
+	return DB.Model(&Template{}).Where("business_account_id = 0 OR business_account_id IS NULL").Update("business_account_id", number.BusinessAccountID).Error
+}
+
+// DefaultBusinessPhoneNumber returns the business phone number defined by the startup options
+func DefaultBusinessPhoneNumber() (*BusinessPhoneNumber, error) {
+	return FindBusinessPhoneNumber(state.PhoneNumberID.Get())
+}
+
+// FindBusinessPhoneNumber returns the business phone number with the graph api id phoneNumberID
+func FindBusinessPhoneNumber(phoneNumberID string) (*BusinessPhoneNumber, error) {
+	number := &BusinessPhoneNumber{}
+	err := DB.Where("phone_number_id = ?", phoneNumberID).First(number).Error
+	if err != nil {
+		return nil, err
+	}
+	return number, nil
+}
+
+// RecipientAllowed returns true if messages can be send to the (parsed) phone number
+func (n *BusinessPhoneNumber) RecipientAllowed(number string) bool {
+	if n.RecipientMode != RecipientModeSandbox {
+		return true
+	}
+	for _, recipient := range n.AllowedRecipients {
+		if recipient == number {
+			return true
+		}
+	}
+	return false
+}
+
+// ConversationRequired returns true if messages that are not templates can only be send to contacts with a conversation
+func (n *BusinessPhoneNumber) ConversationRequired() bool {
+	return n.RecipientMode == RecipientModeConversations || n.RecipientMode == ""
+}
+
+// DisplayPhoneNumber returns the phone number as shown by the graph api
+func (n *BusinessPhoneNumber) DisplayPhoneNumber() string {
+	return "+" + n.PhoneNumber
+}
+
+// FindBusinessAccount returns the WABA with the graph api id wabaID
+func FindBusinessAccount(wabaID string) (*BusinessAccount, error) {
+	account := &BusinessAccount{}
+	err := DB.Where("waba_id = ?", wabaID).First(account).Error
+	if err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// BusinessAccount returns the WABA the phone number belongs to
+func (n *BusinessPhoneNumber) BusinessAccount() (*BusinessAccount, error) {
+	account := &BusinessAccount{}
+	err := DB.First(account, n.BusinessAccountID).Error
+	if err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// CreateSampleTemplates adds the hello_world template to the WABA
+func (a *BusinessAccount) CreateSampleTemplates() error {
+	header := "Hello World"
+	footer := "WhatsApp dev sample message"
+	return DB.Create(&Template{
+		BusinessAccountID: a.ID,
+		Name:              "hello_world",
+		Body:              "Welcome and congratulations!! This message demonstrates your ability to send a WhatsApp message notification from the Cloud API, hosted by whatsapp dev. Thank you for taking the time to test with us.",
+		Header:            &header,
+		Footer:            &footer,
+	}).Error
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+// BusinessProfile is the whatsapp business profile of a business phone number
+type BusinessProfile struct {
+	gorm.Model
+	BusinessPhoneNumberID uint     `json:"businessPhoneNumberId"`
+	About                 string   `json:"about"`
+	Address               string   `json:"address"`
+	Description           string   `json:"description"`
+	Email                 string   `json:"email"`
+	Websites              []string `json:"websites" gorm:"serializer:json"`
+	Vertical              string   `json:"vertical"`
+	// ProfilePictureUploadID is the upload used as profile picture
+	ProfilePictureUploadID *uint   `json:"profilePictureUploadId"`
+	ProfilePicture         *Upload `json:"profilePicture" gorm:"foreignKey:ProfilePictureUploadID"`
+}
+
+// BusinessVerticals are the industries a business profile can have
+var BusinessVerticals = []string{
+	"UNDEFINED",
+	"OTHER",
+	"AUTO",
+	"BEAUTY",
+	"APPAREL",
+	"EDU",
+	"ENTERTAIN",
+	"EVENT_PLAN",
+	"FINANCE",
+	"GROCERY",
+	"GOVT",
+	"HOTEL",
+	"HEALTH",
+	"NONPROFIT",
+	"PROF_SERVICES",
+	"RETAIL",
+	"TRAVEL",
+	"RESTAURANT",
+	"NOT_A_BIZ",
+}
@@ -1,17 +1,71 @@
 package models
 
 import (
+	"encoding/json"
 	"errors"
 
 	. "github.com/mjarkk/whatsapp-dev/go/db"
+	"github.com/mjarkk/whatsapp-dev/go/state"
+	"github.com/mjarkk/whatsapp-dev/go/utils/phonenumber"
 	"gorm.io/gorm"
 )
 
+// DefaultContactName is the profile name used for conversations without a contact name
+const DefaultContactName = "John Doe"
+
 type Conversation struct {
 	gorm.Model
-	PhoneNumberId string    `json:"phoneNumberId"`
-	PhoneNumber   string    `json:"phoneNumber"`
-	Messages      []Message `json:"messages"`
+	// BusinessPhoneNumberID is the business phone number this conversation is with
+	BusinessPhoneNumberID uint   `json:"businessPhoneNumberId"`
+	PhoneNumberId         string `json:"phoneNumberId"`
+	PhoneNumber           string `json:"phoneNumber"`
+	// ContactName is the profile name of the simulated user
+	ContactName string `json:"contactName"`
+	// WaID can be set if the whatsapp id differs from the phone number,
+	// for example for Brazilian and Mexican numbers
+	WaID *string `json:"waId"`
+	// HideProfileName omits the profile name from the webhook contacts like the real api sometimes does
+	HideProfileName bool `json:"hideProfileName"`
+	// PhoneNumberInfo contains the metadata of the phone number, it's only set by the routes returning conversations to the UI
+	PhoneNumberInfo *phonenumber.Info `json:"phoneNumberInfo" gorm:"-"`
+	Messages        []Message         `json:"messages"`
+}
+
+// SetPhoneNumberInfo looks up the metadata of the phone number, numbers that cannot be looked up have no metadata
+func (c *Conversation) SetPhoneNumberInfo() {
+	info, err := phonenumber.Lookup(c.PhoneNumber)
+	if err == nil {
+		c.PhoneNumberInfo = &info
+	}
+}
+
+// DeleteAllConversations permanently deletes all conversations and their messages
+func DeleteAllConversations() error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		for _, model := range []any{&MessageButton{}, &Message{}, &Conversation{}} {
+			err := tx.Session(&gorm.Session{AllowGlobalUpdate: true}).Unscoped().Delete(model).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ContactWaID returns the whatsapp id of the simulated user
+func (c *Conversation) ContactWaID() string {
+	if c.WaID != nil && *c.WaID != "" {
+		return *c.WaID
+	}
+	return c.PhoneNumber
+}
+
+// ContactProfileName returns the profile name of the simulated user
+func (c *Conversation) ContactProfileName() string {
+	if c.ContactName == "" {
+		return DefaultContactName
+	}
+	return c.ContactName
 }
 
 type Message struct {
@@ -25,6 +79,88 @@ type Message struct {
 	Timestamp      int64           `json:"timestamp"`
 	Payload        *string         `json:"payload"`
 	Buttons        []MessageButton `json:"buttons"`
+	// PayloadHash is the hash of the send message request of business messages, see PayloadHash
+	PayloadHash string `json:"-" gorm:"index"`
+	// DuplicateOfID is set if the message is flagged as duplicate of an earlier message
+	DuplicateOfID *uint `json:"duplicateOfId"`
+	// TemplateName is the name of the template used to send a business message
+	TemplateName *string `json:"templateName"`
+	// RawRequest is the body of the send message request of business messages
+	RawRequest string `json:"-"`
+	// RequestHeaders are the headers of the send message request with the access token redacted
+	RequestHeaders map[string]string `json:"-" gorm:"serializer:json"`
+	// RequestURL is the url of the send message request with the access token redacted
+	RequestURL string `json:"-"`
+	// GraphVersion is the graph api version used to send the message
+	GraphVersion string `json:"-"`
+	// RawResponse is the body of the response to the send message request
+	RawResponse string `json:"-"`
+	// HasInspection is true for messages with a stored send message request, see Inspection
+	HasInspection bool `json:"hasInspection" gorm:"-"`
+}
+
+// AfterFind marks messages with a stored send message request
+func (m *Message) AfterFind(tx *gorm.DB) error {
+	m.HasInspection = m.RawRequest != ""
+	return nil
+}
+
+// MessageInspection is the stored send message request and response of a business message
+type MessageInspection struct {
+	MessageID    uint              `json:"messageId"`
+	GraphVersion string            `json:"graphVersion"`
+	Method       string            `json:"method"`
+	URL          string            `json:"url"`
+	Headers      map[string]string `json:"headers"`
+	Request      json.RawMessage   `json:"request"`
+	Response     json.RawMessage   `json:"response"`
+}
+
+// Inspection returns the stored send message request and response, nil is returned for messages without a stored request.
+// Rejected and deduplicated sends create no message so they cannot be inspected, they are only part of the recorded traffic (see the traffic package).
+func (m *Message) Inspection() *MessageInspection {
+	if m.RawRequest == "" {
+		return nil
+	}
+
+	inspection := &MessageInspection{
+		MessageID:    m.ID,
+		GraphVersion: m.GraphVersion,
+		Method:       "POST",
+		URL:          m.RequestURL,
+		Headers:      m.RequestHeaders,
+		Request:      rawJSON(m.RawRequest),
+		Response:     rawJSON(m.RawResponse),
+	}
+	if inspection.Headers == nil {
+		inspection.Headers = map[string]string{}
+	}
+	return inspection
+}
+
+// rawJSON returns value as raw json, values that are not valid json are returned as json string
+func rawJSON(value string) json.RawMessage {
+	if value == "" {
+		return json.RawMessage("null")
+	}
+	if json.Valid([]byte(value)) {
+		return json.RawMessage(value)
+	}
+	encoded, _ := json.Marshal(value)
+	return encoded
+}
+
+// MessageCreated is notified every time a message is created
+var MessageCreated = state.Signal{}
+
+// ConversationsReset is notified when all conversations are removed by a reset, requests waiting for a message stop waiting
+var ConversationsReset = state.Signal{}
+
+// AfterCreate notifies the requests waiting for new messages
+func (m *Message) AfterCreate(tx *gorm.DB) error {
+	m.HasInspection = m.RawRequest != ""
+	MessageCreated.Notify()
+	return nil
 }
 
 type MessageButton struct {
@@ -42,19 +178,34 @@ const (
 	DirectionOut Direction = "out"
 )
 
-func (m *Message) CreateOrAppend(number string) error {
+// FindConversation returns the conversation of the business phone number with the contact, matched on both the phone number and the whatsapp id
+func FindConversation(businessPhoneNumberID uint, number string, waID string) (Conversation, error) {
+	conversation := Conversation{}
+	err := DB.Model(&Conversation{}).
+		Where("business_phone_number_id = ? AND (phone_number = ? OR phone_number = ? OR wa_id = ?)", businessPhoneNumberID, number, waID, waID).
+		First(&conversation).Error
+	return conversation, err
+}
+
+// CreateOrAppend creates the message in the conversation with number, a new conversation is started if there is none.
+// Conversations are matched on both the phone number and the whatsapp id so both forms of Brazilian and Mexican numbers end up in the same conversation.
+func (m *Message) CreateOrAppend(businessPhoneNumberID uint, number string, waID string) error {
 	conversationID := uint(0)
 
-	exsistingConversation := Conversation{}
-	err := DB.Model(&Conversation{}).Where("phone_number = ?", number).First(&exsistingConversation).Error
+	exsistingConversation, err := FindConversation(businessPhoneNumberID, number, waID)
 	if err == nil {
 		// Append messsage to exsisting conversation
 		conversationID = exsistingConversation.ID
 	} else if errors.Is(err, gorm.ErrRecordNotFound) {
 		// Start a new converstaion
 		newConversation := Conversation{
-			PhoneNumberId: number,
-			PhoneNumber:   number,
+			BusinessPhoneNumberID: businessPhoneNumberID,
+			PhoneNumberId:         number,
+			PhoneNumber:           number,
+			ContactName:           DefaultContactName,
+		}
+		if waID != number {
+			newConversation.WaID = &waID
 		}
 		err = DB.Create(&newConversation).Error
 		if err != nil {
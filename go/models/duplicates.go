@@ -0,0 +1,105 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	. "github.com/mjarkk/whatsapp-dev/go/db"
+	"github.com/mjarkk/whatsapp-dev/go/state"
+)
+
+type DuplicateMode string
+
+const (
+	// DuplicateModeOff only stores the payload hashes so duplicates can be listed
+	DuplicateModeOff DuplicateMode = "off"
+	// DuplicateModeFlag flags messages that are a duplicate of a recent message
+	DuplicateModeFlag DuplicateMode = "flag"
+	// DuplicateModeDedupe does not create duplicate messages but returns the id of the original message
+	DuplicateModeDedupe DuplicateMode = "dedupe"
+)
+
+// DuplicateConfig configures the duplicate detection of send message requests
+type DuplicateConfig struct {
+	Mode          DuplicateMode `json:"mode"`
+	WindowSeconds int           `json:"windowSeconds"`
+}
+
+var Duplicates = state.State[DuplicateConfig]{}
+
+// Window returns the duration in which identical send requests are seen as duplicates, defaults to 60 seconds
+func (c DuplicateConfig) Window() time.Duration {
+	if c.WindowSeconds <= 0 {
+		return time.Minute
+	}
+	return time.Duration(c.WindowSeconds) * time.Second
+}
+
+// PayloadHash returns the hash of a send message request, the recipient is hashed separately so formatting differences do not matter
+func PayloadHash(phoneNumberID string, to string, body []byte) string {
+	payload := map[string]any{}
+	err := json.Unmarshal(body, &payload)
+	if err == nil {
+		delete(payload, "to")
+		// Marshal sorts the keys so the hash does not depend on the key order
+		body, _ = json.Marshal(payload)
+	}
+
+	hash := sha256.Sum256([]byte(phoneNumberID + "\n" + to + "\n" + string(body)))
+	return hex.EncodeToString(hash[:])
+}
+
+// FindRecentDuplicate returns the most recent business message with the payload hash within the window or nil if there is none
+func FindRecentDuplicate(payloadHash string, window time.Duration) (*Message, error) {
+	messages := []Message{}
+	err := DB.Where("payload_hash = ? AND direction = ? AND created_at >= ?", payloadHash, DirectionIn, time.Now().Add(-window)).
+		Order("created_at DESC").
+		Limit(1).
+		Find(&messages).Error
+	if err != nil || len(messages) == 0 {
+		return nil, err
+	}
+	return &messages[0], nil
+}
+
+// SuspectedDuplicate is a business message that is identical to an earlier message within the window
+type SuspectedDuplicate struct {
+	ConversationID uint    `json:"conversationId"`
+	Message        Message `json:"message"`
+	DuplicateOf    Message `json:"duplicateOf"`
+	// SecondsApart is the time between the original message and the duplicate
+	SecondsApart float64 `json:"secondsApart"`
+}
+
+// SuspectedDuplicates lists the business messages that have the same payload as an earlier message within the window.
+// If conversationID is 0 the duplicates of all conversations are returned.
+func SuspectedDuplicates(conversationID uint, window time.Duration) ([]SuspectedDuplicate, error) {
+	query := DB.Where("payload_hash != '' AND direction = ?", DirectionIn).Order("conversation_id, created_at")
+	if conversationID != 0 {
+		query = query.Where("conversation_id = ?", conversationID)
+	}
+	messages := []Message{}
+	err := query.Find(&messages).Error
+	if err != nil {
+		return nil, err
+	}
+
+	duplicates := []SuspectedDuplicate{}
+	lastByHash := map[string]Message{}
+	for _, message := range messages {
+		previous, ok := lastByHash[message.PayloadHash]
+		if ok && message.CreatedAt.Sub(previous.CreatedAt) <= window {
+			duplicates = append(duplicates, SuspectedDuplicate{
+				ConversationID: message.ConversationID,
+				Message:        message,
+				DuplicateOf:    previous,
+				SecondsApart:   message.CreatedAt.Sub(previous.CreatedAt).Seconds(),
+			})
+		}
+		lastByHash[message.PayloadHash] = message
+	}
+
+	return duplicates, nil
+}
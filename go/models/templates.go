@@ -12,7 +12,9 @@ import (
 
 type Template struct {
 	gorm.Model
-	Name string `json:"name"`
+	// BusinessAccountID is the WABA this template belongs to
+	BusinessAccountID uint   `json:"businessAccountId"`
+	Name              string `json:"name"`
 	// FIXME different types of headers
 	Header                *string                `json:"header"`
 	Body                  string                 `json:"body"`
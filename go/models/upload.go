@@ -0,0 +1,20 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+// Upload is a file uploaded using the resumable upload api
+type Upload struct {
+	gorm.Model
+	// SessionID is the id of the upload session without the "upload:" prefix
+	SessionID  string `json:"sessionId"`
+	FileName   string `json:"fileName"`
+	FileType   string `json:"fileType"`
+	FileLength int64  `json:"fileLength"`
+	Data       []byte `json:"-"`
+	// Handle is set once the upload is complete, it can be used to reference the file in other requests
+	Handle *string `json:"handle"`
+	// MediaID is set for files uploaded using POST /{phone-number-id}/media
+	MediaID *string `json:"mediaId" gorm:"index"`
+}
@@ -0,0 +1,138 @@
+package src
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/mjarkk/whatsapp-dev/go/controller/business"
+	. "github.com/mjarkk/whatsapp-dev/go/db"
+	"github.com/mjarkk/whatsapp-dev/go/lib/bots"
+	"github.com/mjarkk/whatsapp-dev/go/lib/expectations"
+	"github.com/mjarkk/whatsapp-dev/go/lib/faults"
+	"github.com/mjarkk/whatsapp-dev/go/lib/graph"
+	"github.com/mjarkk/whatsapp-dev/go/lib/ratelimit"
+	"github.com/mjarkk/whatsapp-dev/go/lib/traffic"
+	"github.com/mjarkk/whatsapp-dev/go/lib/webhook"
+	"github.com/mjarkk/whatsapp-dev/go/models"
+	"github.com/mjarkk/whatsapp-dev/go/state"
+	"github.com/mjarkk/whatsapp-dev/go/utils/phonenumber"
+	"github.com/mjarkk/whatsapp-dev/go/utils/wamid"
+)
+
+// SetupOptions are the options for Setup
+type SetupOptions struct {
+	GraphToken         string
+	AppID              string
+	AppSecret          string
+	PhoneNumber        string
+	PhoneNumberID      string
+	BusinessAccountID  string
+	WebhookURL         string
+	WebhookVerifyToken string
+	WebhookBatchWindow time.Duration
+	WebhookStatuses    bool
+	DefaultRegion      string
+	WaIDQuirks         bool
+	// WamidSeed seeds the generation of message ids
+	WamidSeed int64
+	// IDSeed seeds the generation of the ids of business accounts and phone numbers created using the api
+	IDSeed int64
+}
+
+// Setup sets the global state, migrates the database and creates the default business phone number, access token and sample templates.
+// The database must be connected before calling Setup.
+func Setup(opts SetupOptions) error {
+	state.GraphToken.Set(opts.GraphToken)
+	state.AppID.Set(opts.AppID)
+	state.AppSecret.Set(opts.AppSecret)
+	state.PhoneNumber.Set(opts.PhoneNumber)
+	state.PhoneNumberID.Set(opts.PhoneNumberID)
+	state.BusinessAccountID.Set(opts.BusinessAccountID)
+	state.WebhookURL.Set(opts.WebhookURL)
+	state.WebhookVerifyToken.Set(opts.WebhookVerifyToken)
+	state.WebhookBatchWindow.Set(opts.WebhookBatchWindow)
+	state.WebhookStatuses.Set(opts.WebhookStatuses)
+	phonenumber.DefaultRegion.Set(opts.DefaultRegion)
+	phonenumber.WaIDQuirks.Set(opts.WaIDQuirks)
+
+	graph.Versions.Set(graph.DefaultVersions())
+	ratelimit.Limits.Set(ratelimit.Config{})
+	ratelimit.Reset()
+	models.Duplicates.Set(models.DuplicateConfig{})
+	faults.Clear()
+	expectations.Clear()
+	traffic.Clear()
+	bots.Clear()
+	webhook.CancelPending()
+
+	err := DB.AutoMigrate(
+		&models.Conversation{},
+		&models.Message{},
+		&models.Template{},
+		&models.TemplateCustomButton{},
+		&models.MessageButton{},
+		&models.BusinessAccount{},
+		&models.BusinessPhoneNumber{},
+		&models.BusinessProfile{},
+		&models.Upload{},
+		&models.AccessToken{},
+	)
+	if err != nil {
+		return err
+	}
+
+	// Offset the seed by the amount of stored messages so ids are reproducible for a fresh database without repeating earlier ids after a restart
+	messagesCount := int64(0)
+	err = DB.Model(&models.Message{}).Count(&messagesCount).Error
+	if err != nil {
+		return err
+	}
+	wamid.SetRandomSource(rand.New(rand.NewSource(opts.WamidSeed + messagesCount)))
+
+	// Same for the business accounts and phone numbers
+	businessCount := int64(0)
+	err = DB.Model(&models.BusinessAccount{}).Count(&businessCount).Error
+	if err != nil {
+		return err
+	}
+	phoneNumbersCount := int64(0)
+	err = DB.Model(&models.BusinessPhoneNumber{}).Count(&phoneNumbersCount).Error
+	if err != nil {
+		return err
+	}
+	business.SetRandomSource(rand.New(rand.NewSource(opts.IDSeed + businessCount + phoneNumbersCount)))
+
+	err = models.EnsureDefaultAccessToken(opts.GraphToken)
+	if err != nil {
+		return err
+	}
+
+	err = models.EnsureDefaultBusiness(opts.BusinessAccountID, opts.PhoneNumber, opts.PhoneNumberID)
+	if err != nil {
+		return err
+	}
+
+	defaultPhoneNumber, err := models.DefaultBusinessPhoneNumber()
+	if err != nil {
+		return err
+	}
+
+	templatesCount := int64(0)
+	err = DB.Model(&models.Template{}).Where("business_account_id = ?", defaultPhoneNumber.BusinessAccountID).Count(&templatesCount).Error
+	if err != nil {
+		return err
+	}
+
+	if templatesCount == 0 {
+		defaultBusinessAccount, err := defaultPhoneNumber.BusinessAccount()
+		if err != nil {
+			return err
+		}
+		err = defaultBusinessAccount.CreateSampleTemplates()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
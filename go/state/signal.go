@@ -0,0 +1,31 @@
+package state
+
+import "sync"
+
+// Signal wakes up everyone waiting for the next change
+type Signal struct {
+	lock    sync.Mutex
+	changed chan struct{}
+}
+
+// Wait returns a channel that is closed on the next call to Notify
+func (s *Signal) Wait() <-chan struct{} {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.changed == nil {
+		s.changed = make(chan struct{})
+	}
+	return s.changed
+}
+
+// Notify wakes up everyone waiting
+func (s *Signal) Notify() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.changed != nil {
+		close(s.changed)
+		s.changed = nil
+	}
+}
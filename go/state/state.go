@@ -1,14 +1,21 @@
 package state
 
-import "sync"
+import (
+	"sync"
+	"time"
+)
 
 var (
 	GraphToken         = State[string]{}
+	AppID              = State[string]{}
 	AppSecret          = State[string]{}
 	PhoneNumber        = State[string]{}
 	PhoneNumberID      = State[string]{}
+	BusinessAccountID  = State[string]{}
 	WebhookURL         = State[string]{}
 	WebhookVerifyToken = State[string]{}
+	WebhookBatchWindow = State[time.Duration]{}
+	WebhookStatuses = State[bool]{}
 )
 
 type State[T any] struct {
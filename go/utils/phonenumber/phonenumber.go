@@ -1,49 +1,185 @@
 package phonenumber
 
 import (
-	"encoding/base64"
 	"errors"
-	"math/rand"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
 
 	"github.com/dongri/phonenumber"
+	"github.com/mjarkk/whatsapp-dev/go/state"
 )
 
+// DefaultRegion is the region used to parse local phone numbers (starting with a 0)
+var DefaultRegion = state.State[string]{}
+
+// WaIDQuirks enables the country specific differences between phone numbers and whatsapp ids, see WaID
+var WaIDQuirks = state.State[bool]{}
+
 type ParsedPhoneNumber struct {
-	Original          string
-	Parsed            string
-	WhatsappMessageID string
+	// Original is the phone number as it was given, like the input of the send message response
+	Original string
+	// Parsed is the phone number in E.164 format without the + prefix
+	Parsed string
+	// WaID is the whatsapp id of the phone number, this can differ from Parsed, see WaID
+	WaID string
+	Info Info
+}
+
+type LineType string
+
+const (
+	LineTypeMobile    LineType = "MOBILE"
+	LineTypeFixedLine LineType = "FIXED_LINE"
+)
+
+// Info contains the metadata of a valid phone number
+type Info struct {
+	E164        string   `json:"e164"`
+	Country     string   `json:"country"`
+	CountryName string   `json:"countryName"`
+	CountryCode string   `json:"countryCode"`
+	LineType    LineType `json:"lineType"`
+}
+
+// ValidRegion returns true if region is a known ISO 3166 alpha-2 country code
+func ValidRegion(region string) bool {
+	region = strings.ToUpper(region)
+	for _, country := range phonenumber.GetISO3166() {
+		if country.Alpha2 == region {
+			return true
+		}
+	}
+	return false
 }
 
+// Parse parses a phone number, local phone numbers are parsed using the default region
 func Parse(input string, localAllowed bool) (*ParsedPhoneNumber, error) {
+	return ParseInRegion(input, "", localAllowed)
+}
+
+// ParseInRegion parses a phone number, local phone numbers are parsed using region or the default region if region is empty.
+// Numbers without a + or 0 prefix are expected to start with the country code.
+func ParseInRegion(original string, region string, localAllowed bool) (*ParsedPhoneNumber, error) {
+	input := strings.TrimSpace(original)
 	if len(input) < 6 {
 		return nil, errors.New("phone number too short")
 	}
+
+	var number string
 	if input[0] == '0' {
 		if !localAllowed {
 			return nil, errors.New("local phone number not allowed")
 		}
-	} else if input[0] != '+' {
-		input = "+" + input
+		if region == "" {
+			region = DefaultRegion.Get()
+		}
+		if region == "" {
+			region = "NL"
+		}
+		if !ValidRegion(region) {
+			return nil, errors.New("unknown region " + region)
+		}
+		number = phonenumber.ParseWithLandLine(input, region)
+		if number == "" {
+			return nil, fmt.Errorf("invalid local phone number for region %s", strings.ToUpper(region))
+		}
+	} else {
+		number = nonDigitsRegex.ReplaceAllString(input, "")
+	}
+
+	info, err := Lookup(number)
+	if err != nil {
+		return nil, err
 	}
-	number := phonenumber.Parse(input, "NL")
 
 	return &ParsedPhoneNumber{
-		Original:          input,
-		Parsed:            number,
-		WhatsappMessageID: CreateWhatsappID(number),
+		Original: original,
+		Parsed:   number,
+		WaID:     WaID(number),
+		Info:     info,
 	}, nil
 }
 
-func CreateWhatsappID(phoneNumber string) string {
-	idRandomBytes := []byte{}
-	for i := 0; i < 24; i++ {
-		idRandomBytes = append(idRandomBytes, byte(rand.Intn(256)))
+var nonDigitsRegex = regexp.MustCompile(`\D`)
+
+// Lookup validates a phone number in E.164 format (with or without the + prefix) and returns its metadata
+func Lookup(number string) (Info, error) {
+	number = nonDigitsRegex.ReplaceAllString(number, "")
+	if len(number) > 15 {
+		return Info{}, errors.New("invalid length, E.164 phone numbers have at most 15 digits")
 	}
 
-	// wamid.HBgLMzE2MTExMzg3NTIVAgARGBIzRTQ0RDBCODQyMDFDQjkzQUYA
-	id := []byte{0x1C, 0x18, 0x0B, 0x33}
-	id = append(id, []byte(phoneNumber)...)
-	id = append(id, idRandomBytes...)
-	id = append(id, 0)
-	return "wamid." + base64.StdEncoding.EncodeToString(id)
+	country := phonenumber.GetISO3166ByNumber(number, true)
+	if country.Alpha2 == "" {
+		return Info{}, lookupError(number)
+	}
+
+	lineType := LineTypeFixedLine
+	if mobileCountry := phonenumber.GetISO3166ByNumber(number, false); mobileCountry.Alpha2 != "" {
+		country = mobileCountry
+		lineType = LineTypeMobile
+	}
+
+	return Info{
+		E164:        "+" + number,
+		Country:     country.Alpha2,
+		CountryName: country.CountryName,
+		CountryCode: country.CountryCode,
+		LineType:    lineType,
+	}, nil
+}
+
+// WaID returns the whatsapp id of a phone number in E.164 format without the + prefix.
+// If WaIDQuirks is enabled this emulates the differences of the real platform:
+// Brazilian mobile numbers with an area code of 31 or higher (so not São Paulo, Rio de Janeiro and Espírito Santo)
+// lose the extra 9 after the area code (55 31 9xxxxxxxx becomes 55 31 xxxxxxxx)
+// and Mexican mobile numbers get a 1 after the country code (52 xxxxxxxxxx becomes 52 1 xxxxxxxxxx).
+func WaID(number string) string {
+	if !WaIDQuirks.Get() {
+		return number
+	}
+
+	if len(number) == 13 && strings.HasPrefix(number, "55") && number[4] == '9' {
+		areaCode, err := strconv.Atoi(number[2:4])
+		if err == nil && areaCode >= 31 {
+			return number[:4] + number[5:]
+		}
+		return number
+	}
+	if len(number) == 12 && strings.HasPrefix(number, "52") {
+		// Mexican mobile and fixed line numbers cannot be told apart by their prefix so the phone number metadata marks every Mexican number as mobile
+		info, err := Lookup(number)
+		if err == nil && info.LineType == LineTypeMobile {
+			return "521" + number[2:]
+		}
+	}
+	return number
+}
+
+// lookupError explains why a phone number is not valid
+func lookupError(number string) error {
+	var match *phonenumber.ISO3166
+	for _, country := range phonenumber.GetISO3166() {
+		if strings.HasPrefix(number, country.CountryCode) && (match == nil || len(country.CountryCode) > len(match.CountryCode)) {
+			country := country
+			match = &country
+		}
+	}
+	if match == nil {
+		return errors.New("unknown country code")
+	}
+
+	lengths := []string{}
+	for _, length := range match.PhoneNumberLengths {
+		lengths = append(lengths, strconv.Itoa(length))
+	}
+	return fmt.Errorf(
+		"invalid length, phone numbers of %s (+%s) have %s digits after the country code, got %d",
+		match.CountryName,
+		match.CountryCode,
+		strings.Join(lengths, " or "),
+		len(number)-len(match.CountryCode),
+	)
 }
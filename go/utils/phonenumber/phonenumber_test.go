@@ -0,0 +1,68 @@
+package phonenumber
+
+import "testing"
+
+func TestWaID(t *testing.T) {
+	WaIDQuirks.Set(true)
+	defer WaIDQuirks.Set(false)
+
+	tests := []struct {
+		name     string
+		number   string
+		expected string
+	}{
+		{"dutch mobile", "31612345678", "31612345678"},
+		{"brazil minas gerais mobile", "5531912345678", "553112345678"},
+		{"brazil bahia mobile", "5571912345678", "557112345678"},
+		{"brazil sao paulo mobile keeps the 9", "5511912345678", "5511912345678"},
+		{"brazil rio de janeiro mobile keeps the 9", "5521912345678", "5521912345678"},
+		{"brazil already without 9", "553112345678", "553112345678"},
+		{"brazil fixed line", "553132345678", "553132345678"},
+		{"mexico mobile", "525512345678", "5215512345678"},
+		{"mexico already with 1", "5215512345678", "5215512345678"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			waID := WaID(test.number)
+			if waID != test.expected {
+				t.Fatalf("expected %s, got %s", test.expected, waID)
+			}
+		})
+	}
+
+	WaIDQuirks.Set(false)
+	if waID := WaID("5531912345678"); waID != "5531912345678" {
+		t.Fatalf("expected the number to be unchanged without quirks, got %s", waID)
+	}
+}
+
+func TestParseKeepsTheOriginalInput(t *testing.T) {
+	DefaultRegion.Set("NL")
+	defer DefaultRegion.Set("")
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"31612345678", "31612345678"},
+		{"+31612345678", "31612345678"},
+		{"+31 6 12345678", "31612345678"},
+		{"0612345678", "31612345678"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			parsed, err := Parse(test.input, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if parsed.Original != test.input {
+				t.Fatalf("expected the original input %q, got %q", test.input, parsed.Original)
+			}
+			if parsed.Parsed != test.expected || parsed.WaID != test.expected {
+				t.Fatalf("expected the parsed number and wa_id %s, got %s and %s", test.expected, parsed.Parsed, parsed.WaID)
+			}
+		})
+	}
+}
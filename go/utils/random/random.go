@@ -1,6 +1,7 @@
 package random
 
 import (
+	"crypto/sha256"
 	"encoding/base64"
 	"encoding/binary"
 	"encoding/hex"
@@ -32,12 +33,37 @@ func Numbers(r *rand.Rand, size int) string {
 	return resp
 }
 
+const alphanumericChars = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+
+func Alphanumeric(r *rand.Rand, size int) string {
+	resp := make([]byte, size)
+	for i := range resp {
+		resp[i] = alphanumericChars[r.Intn(len(alphanumericChars))]
+	}
+	return string(resp)
+}
+
+// SeededSource returns a random source that always generates the same values for seed
+func SeededSource(seed string) *rand.Rand {
+	h := sha256.New()
+	h.Write([]byte(seed))
+	result := h.Sum(nil)
+	var seedValue int64
+	for idx, b := range result {
+		seedValue ^= int64(b) << idx * 2
+	}
+
+	return rand.New(rand.NewSource(seedValue))
+}
+
 type RandomValues struct {
 	PhoneNumber        string
 	PhoneNumberID      string
 	GraphToken         string
 	AppSecret          string
 	WebhookVerifyToken string
+	BusinessAccountID  string
+	AppID              string
 }
 
 func GetRandomValuesForSetup(r *rand.Rand) RandomValues {
@@ -47,5 +73,7 @@ func GetRandomValuesForSetup(r *rand.Rand) RandomValues {
 		GraphToken:         base64.StdEncoding.EncodeToString(Bytes(r, 172)),
 		AppSecret:          Hex(r, 16),
 		WebhookVerifyToken: Hex(r, 16),
+		BusinessAccountID:  Numbers(r, 15),
+		AppID:              Numbers(r, 16),
 	}
 }
@@ -0,0 +1,198 @@
+package wamid
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mjarkk/whatsapp-dev/go/utils/random"
+)
+
+// Prefix is the prefix of every whatsapp message id
+const Prefix = "wamid."
+
+// Direction tells if a message is send by the business (outbound) or by the contact (inbound)
+type Direction string
+
+const (
+	Inbound  Direction = "inbound"
+	Outbound Direction = "outbound"
+)
+
+// ID is the decoded content of a whatsapp message id
+type ID struct {
+	// WaID is the whatsapp id of the contact the message is send to or received from
+	WaID      string    `json:"waId"`
+	Direction Direction `json:"direction"`
+	// MessageID is the id of the message within the chat
+	MessageID string `json:"messageId"`
+}
+
+var (
+	randomLock   sync.Mutex
+	randomSource = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+// SetRandomSource sets the random source used to generate message ids, a seeded source makes the generated ids reproducible
+func SetRandomSource(r *rand.Rand) {
+	randomLock.Lock()
+	randomSource = r
+	randomLock.Unlock()
+}
+
+// Thrift compact protocol bytes used by whatsapp message ids
+const (
+	fieldStruct        = 0x1C
+	fieldBinary        = 0x18
+	fieldI32           = 0x15
+	fieldBoolTrue      = 0x11
+	fieldBoolFalse     = 0x12
+	fieldStop          = 0x00
+	chatTypeIndividual = 0x02 // zigzag encoded 1
+)
+
+// New creates a whatsapp message id for a message send to or received from waID.
+//
+// The id is a base64 encoded thrift compact struct like the real platform uses:
+//
+//	struct chat {
+//	  binary wa_id
+//	  i32    type
+//	}
+//	bool   from_business
+//	binary message_id
+//
+// Messages send by the business get a 18 character hex message id,
+// messages received from the contact get a 20 character message id starting with 3A like ids created by the iOS app.
+func New(waID string, direction Direction) string {
+	randomLock.Lock()
+	var messageID string
+	if direction == Outbound {
+		messageID = strings.ToUpper(random.Hex(randomSource, 9))
+	} else {
+		messageID = "3A" + strings.ToUpper(random.Hex(randomSource, 9))
+	}
+	randomLock.Unlock()
+
+	return Encode(ID{WaID: waID, Direction: direction, MessageID: messageID})
+}
+
+// Encode encodes id into a whatsapp message id
+func Encode(id ID) string {
+	data := []byte{fieldStruct, fieldBinary}
+	data = binary.AppendUvarint(data, uint64(len(id.WaID)))
+	data = append(data, id.WaID...)
+	data = append(data, fieldI32, chatTypeIndividual, fieldStop)
+	if id.Direction == Outbound {
+		data = append(data, fieldBoolTrue)
+	} else {
+		data = append(data, fieldBoolFalse)
+	}
+	data = append(data, fieldBinary)
+	data = binary.AppendUvarint(data, uint64(len(id.MessageID)))
+	data = append(data, id.MessageID...)
+	data = append(data, fieldStop)
+	return Prefix + base64.StdEncoding.EncodeToString(data)
+}
+
+// Decode decodes a whatsapp message id
+func Decode(wamid string) (ID, error) {
+	id := ID{}
+
+	encoded, found := strings.CutPrefix(strings.TrimSpace(wamid), Prefix)
+	if !found {
+		return id, errors.New("message id must start with " + Prefix)
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		data, err = base64.RawStdEncoding.DecodeString(strings.TrimRight(encoded, "="))
+	}
+	if err != nil {
+		return id, errors.New("message id is not valid base64")
+	}
+
+	reader := &reader{data: data}
+	reader.expect(fieldStruct, "chat struct")
+	reader.expect(fieldBinary, "wa_id field")
+	id.WaID = reader.binary("wa_id")
+	reader.expect(fieldI32, "chat type field")
+	reader.expect(chatTypeIndividual, "individual chat type")
+	reader.expect(fieldStop, "end of chat struct")
+	switch reader.byte("from_business field") {
+	case fieldBoolTrue:
+		id.Direction = Outbound
+	case fieldBoolFalse:
+		id.Direction = Inbound
+	default:
+		if reader.err == nil {
+			reader.err = errors.New("invalid from_business field")
+		}
+	}
+	reader.expect(fieldBinary, "message_id field")
+	id.MessageID = reader.binary("message_id")
+	reader.expect(fieldStop, "end of message id")
+	if reader.err != nil {
+		return ID{}, reader.err
+	}
+	if reader.offset != len(data) {
+		return ID{}, errors.New("unexpected data after end of message id")
+	}
+
+	return id, nil
+}
+
+// reader reads thrift compact values and remembers the first error
+type reader struct {
+	data   []byte
+	offset int
+	err    error
+}
+
+func (r *reader) byte(name string) byte {
+	if r.err != nil {
+		return 0
+	}
+	if r.offset >= len(r.data) {
+		r.err = errors.New("message id too short, missing " + name)
+		return 0
+	}
+	b := r.data[r.offset]
+	r.offset++
+	return b
+}
+
+func (r *reader) expect(expected byte, name string) {
+	b := r.byte(name)
+	if r.err == nil && b != expected {
+		r.err = errors.New("invalid message id, expected " + name + " but got 0x" + hex.EncodeToString([]byte{b}))
+	}
+}
+
+// binary reads a binary value, the length is a varint like all thrift compact lengths
+func (r *reader) binary(name string) string {
+	if r.err != nil {
+		return ""
+	}
+	length, size := binary.Uvarint(r.data[r.offset:])
+	if size == 0 {
+		r.err = errors.New("message id too short, missing " + name + " length")
+		return ""
+	}
+	if size < 0 {
+		r.err = errors.New("invalid message id, " + name + " length overflows")
+		return ""
+	}
+	r.offset += size
+	if length > uint64(len(r.data)-r.offset) {
+		r.err = errors.New("message id too short, " + name + " is cut off")
+		return ""
+	}
+	value := string(r.data[r.offset : r.offset+int(length)])
+	r.offset += int(length)
+	return value
+}
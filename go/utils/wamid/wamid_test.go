@@ -0,0 +1,65 @@
+package wamid
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestDecode(t *testing.T) {
+	id, err := Decode("wamid.HBgLMzE2MTExMzg3NTIVAgARGBIzRTQ0RDBCODQyMDFDQjkzQUYA")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	expected := ID{WaID: "31611138752", Direction: Outbound, MessageID: "3E44D0B84201CB93AF"}
+	if id != expected {
+		t.Fatalf("expected %+v, got %+v", expected, id)
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	tests := map[string]string{
+		"HBgLMzE2MTExMzg3NTIVAgARGBIzRTQ0RDBCODQyMDFDQjkzQUYA": "must start with",
+		"wamid.not base64!":                      "not valid base64",
+		"wamid.HBgLMzE2MTEx":                     "wa_id is cut off",
+		"wamid.HBg=":                             "missing wa_id length",
+		"wamid.HBj/////////////AQ==":             "length overflows",
+		"wamid.HBgLMzE2MTExMzg3NTIVAgARGBIzRTQ0": "message_id is cut off",
+	}
+
+	for wamid, expected := range tests {
+		_, err := Decode(wamid)
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("Decode(%q) expected an error containing %q, got %v", wamid, expected, err)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	SetRandomSource(rand.New(rand.NewSource(1)))
+
+	for _, direction := range []Direction{Inbound, Outbound} {
+		wamid := New("31612345678", direction)
+		id, err := Decode(wamid)
+		if err != nil {
+			t.Fatalf("unexpected error decoding %s: %s", wamid, err.Error())
+		}
+		if id.WaID != "31612345678" || id.Direction != direction {
+			t.Fatalf("expected wa_id 31612345678 and direction %s, got %+v", direction, id)
+		}
+		if Encode(id) != wamid {
+			t.Fatalf("expected %s to encode back to the same message id, got %s", wamid, Encode(id))
+		}
+	}
+
+	// Lengths of 128 and more take two varint bytes
+	long := ID{WaID: strings.Repeat("1", 200), Direction: Inbound, MessageID: strings.Repeat("A", 130)}
+	id, err := Decode(Encode(long))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if id != long {
+		t.Fatalf("expected %+v, got %+v", long, id)
+	}
+}
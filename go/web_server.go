@@ -13,6 +13,7 @@ import (
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/filesystem"
 	"github.com/gofiber/fiber/v2/middleware/logger"
+	"github.com/mjarkk/whatsapp-dev/go/lib/traffic"
 )
 
 // ErrorResponse is the response send by the server when an error occurs
@@ -27,10 +28,20 @@ type StartWebserverOptions struct {
 	BasicAuthPassword string
 	Rand              *rand.Rand
 	Dist              embed.FS
+	// DisableLogger disables the request logger, used by test servers
+	DisableLogger bool
 }
 
 // StartWebserver starts the webserver
 func StartWebserver(opts StartWebserverOptions) {
+	app := NewApp(opts)
+
+	fmt.Println("Running Web server at", opts.Addr)
+	log.Fatal(app.Listen(opts.Addr))
+}
+
+// NewApp creates the fiber app with all routes, opts.Addr is ignored
+func NewApp(opts StartWebserverOptions) *fiber.App {
 	app := fiber.New(fiber.Config{
 		DisableStartupMessage: true,
 	})
@@ -49,7 +60,10 @@ func StartWebserver(opts StartWebserverOptions) {
 
 	app.Use(compress.New())
 	app.Use(cors.New())
-	app.Use(logger.New())
+	if !opts.DisableLogger {
+		app.Use(logger.New())
+	}
+	app.Use(traffic.Middleware)
 
 	apiRoutes(app.Group("/api", authMiddleware))
 	mockRoutes(app.Group(""))
@@ -60,6 +74,5 @@ func StartWebserver(opts StartWebserverOptions) {
 		Root:       http.FS(opts.Dist),
 	}))
 
-	fmt.Println("Running Web server at", opts.Addr)
-	log.Fatal(app.Listen(opts.Addr))
+	return app
 }
@@ -1,19 +1,19 @@
 package main
 
 import (
-	"crypto/sha256"
 	"embed"
 	"fmt"
-	"math/rand"
 	"net/url"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	. "github.com/mjarkk/whatsapp-dev/go"
 	. "github.com/mjarkk/whatsapp-dev/go/db"
+	"github.com/mjarkk/whatsapp-dev/go/lib/bots"
 	"github.com/mjarkk/whatsapp-dev/go/lib/webhook"
-	"github.com/mjarkk/whatsapp-dev/go/models"
-	"github.com/mjarkk/whatsapp-dev/go/state"
+	"github.com/mjarkk/whatsapp-dev/go/utils/phonenumber"
 	"github.com/mjarkk/whatsapp-dev/go/utils/random"
 
 	"github.com/spf13/pflag"
@@ -50,8 +50,15 @@ func main() {
 	httpPassword := argOrEnv("http-password", "p", "HTTP_PASSWORD", "", "HTTP password")
 	phoneNumber := argOrEnv("whatsapp-phone-number", "", "WHATSAPP_PHONE_NUMBER", "", "Define the mocked phone number")
 	phoneNumberID := argOrEnv("whatsapp-phone-number-id", "", "WHATSAPP_PHONE_NUMBER_ID", "", "Define the mocked phone number id")
+	businessAccountID := argOrEnv("whatsapp-business-account-id", "", "WHATSAPP_BUSINESS_ACCOUNT_ID", "", "Define the mocked WhatsApp business account id")
 	graphToken := argOrEnv("facebook-graph-token", "", "FACEBOOK_GRAPH_TOKEN", "", "Define mock graph token")
+	appID := argOrEnv("facebook-app-id", "", "FACEBOOK_APP_ID", "", "Define the Facebook app id")
 	appSecret := argOrEnv("facebook-app-secret", "", "FACEBOOK_APP_SECRET", "", "Define the Facebook app secret")
+	defaultRegion := argOrEnv("default-region", "", "DEFAULT_REGION", "NL", "Region (ISO 3166 alpha-2) used to parse local phone numbers")
+	waIDQuirks := argOrEnv("wa-id-quirks", "", "WA_ID_QUIRKS", "false", "Emulate the whatsapp ids of Brazilian and Mexican phone numbers that differ from the phone number")
+	botsFile := argOrEnv("bots-file", "", "BOTS_FILE", "", "YAML or JSON file with bots that simulate users")
+	webhookBatchWindow := argOrEnv("webhook-batch-window", "", "WEBHOOK_BATCH_WINDOW", "", "Coalesce webhook events within this window into one request (e.g. 2s)")
+	webhookStatuses := argOrEnv("webhook-statuses", "", "WEBHOOK_STATUSES", "false", "Send the sent and delivered statuses of messages sent by the business to the webhook")
 
 	pflag.Parse()
 
@@ -64,6 +71,29 @@ func main() {
 		panic("Invalid webhook url: " + err.Error())
 	}
 
+	defaultRegionValue := strings.ToUpper(defaultRegion())
+	if !phonenumber.ValidRegion(defaultRegionValue) {
+		panic("Invalid default region: " + defaultRegionValue)
+	}
+
+	waIDQuirksValue, err := strconv.ParseBool(waIDQuirks())
+	if err != nil {
+		panic("Invalid wa id quirks value, expected true or false")
+	}
+
+	webhookStatusesValue, err := strconv.ParseBool(webhookStatuses())
+	if err != nil {
+		panic("Invalid webhook statuses value, expected true or false")
+	}
+
+	var webhookBatchWindowValue time.Duration
+	if webhookBatchWindow() != "" {
+		webhookBatchWindowValue, err = time.ParseDuration(webhookBatchWindow())
+		if err != nil {
+			panic("Invalid webhook batch window: " + err.Error())
+		}
+	}
+
 	secretesSeedValue := secretesSeed()
 	if secretesSeedValue == "" {
 		fmt.Println("DANGER: using fallback secrets seed")
@@ -72,22 +102,21 @@ func main() {
 		secretesSeedValue = "fallback-secrets-seed"
 	}
 
-	h := sha256.New()
-	h.Write([]byte(secretesSeedValue))
-	result := h.Sum(nil)
-	var seed int64
-	for idx, b := range result {
-		seed ^= int64(b) << idx * 2
-	}
-
-	r := rand.New(rand.NewSource(seed))
+	r := random.SeededSource(secretesSeedValue)
 	initialRandomValues := random.GetRandomValuesForSetup(r)
+	wamidSeed := r.Int63()
+	idSeed := r.Int63()
 
 	graphTokenValue := graphToken()
 	if graphTokenValue == "" {
 		graphTokenValue = initialRandomValues.GraphToken
 	}
 
+	appIDValue := appID()
+	if appIDValue == "" {
+		appIDValue = initialRandomValues.AppID
+	}
+
 	appSecretValue := appSecret()
 	if appSecretValue == "" {
 		appSecretValue = initialRandomValues.AppSecret
@@ -103,49 +132,51 @@ func main() {
 		phoneNumberIDValue = initialRandomValues.PhoneNumberID
 	}
 
+	businessAccountIDValue := businessAccountID()
+	if businessAccountIDValue == "" {
+		businessAccountIDValue = initialRandomValues.BusinessAccountID
+	}
+
 	webhookVerifyTokenValue := webHookVerivyToken()
 	if webhookVerifyTokenValue == "" {
 		webhookVerifyTokenValue = initialRandomValues.WebhookVerifyToken
 	}
 
 	fmt.Println("Graph token:\t", graphTokenValue)
-	state.GraphToken.Set(graphTokenValue)
+	fmt.Println("App ID:\t\t", appIDValue)
 	fmt.Println("App secret:\t", appSecretValue)
-	state.AppSecret.Set(appSecretValue)
 	fmt.Println("Phone number:\t", phoneNumberValue)
-	state.PhoneNumber.Set(phoneNumberValue)
 	fmt.Println("Phone number ID:", phoneNumberIDValue)
-	state.PhoneNumberID.Set(phoneNumberIDValue)
+	fmt.Println("Business account ID:", businessAccountIDValue)
 	fmt.Println("Webhook verify token:", webhookVerifyTokenValue)
-	state.WebhookVerifyToken.Set(webhookVerifyTokenValue)
-
-	state.WebhookURL.Set(webHookURLValue)
 
 	ConnectToDatabase()
 
-	DB.AutoMigrate(
-		&models.Conversation{},
-		&models.Message{},
-		&models.Template{},
-		&models.TemplateCustomButton{},
-		&models.MessageButton{},
-	)
-
-	templatesCount := int64(0)
-	err = DB.Model(&models.Template{}).Count(&templatesCount).Error
+	err = Setup(SetupOptions{
+		GraphToken:         graphTokenValue,
+		AppID:              appIDValue,
+		AppSecret:          appSecretValue,
+		PhoneNumber:        phoneNumberValue,
+		PhoneNumberID:      phoneNumberIDValue,
+		BusinessAccountID:  businessAccountIDValue,
+		WebhookURL:         webHookURLValue,
+		WebhookVerifyToken: webhookVerifyTokenValue,
+		WebhookBatchWindow: webhookBatchWindowValue,
+		WebhookStatuses:    webhookStatusesValue,
+		DefaultRegion:      defaultRegionValue,
+		WaIDQuirks:         waIDQuirksValue,
+		WamidSeed:          wamidSeed,
+		IDSeed:             idSeed,
+	})
 	if err != nil {
 		panic(err)
 	}
 
-	if templatesCount == 0 {
-		header := "Hello World"
-		footer := "WhatsApp dev sample message"
-		DB.Create(&models.Template{
-			Name:   "hello_world",
-			Body:   "Welcome and congratulations!! This message demonstrates your ability to send a WhatsApp message notification from the Cloud API, hosted by whatsapp dev. Thank you for taking the time to test with us.",
-			Header: &header,
-			Footer: &footer,
-		})
+	if botsFile() != "" {
+		err = bots.LoadFile(botsFile())
+		if err != nil {
+			panic("Invalid bots file: " + err.Error())
+		}
 	}
 
 	go func() {
@@ -0,0 +1,206 @@
+import { Button } from "@/components/ui/button"
+import { Input } from "@/components/ui/input"
+import { fetch, post } from "@/services/fetch"
+import { FormEvent, useEffect, useState } from "react"
+import { OpenCloseButton } from "../openCloseButton"
+import {
+	useBusinessStore,
+	type BusinessAccount,
+	type BusinessPhoneNumber,
+	type RecipientMode,
+} from "@/services/state"
+
+export function BusinessAccounts() {
+	const [open, setOpen] = useState(false)
+	const { businessAccounts, setBusinessAccounts } = useBusinessStore()
+
+	const getData = async () => {
+		const response = await fetch("/api/businessAccounts")
+		setBusinessAccounts(await response.json())
+	}
+
+	useEffect(() => {
+		getData()
+	}, [])
+
+	const onCreateAccount = async (e: FormEvent<HTMLFormElement>) => {
+		e.preventDefault()
+
+		const target = e.target as HTMLFormElement
+		const name = Object.fromEntries(new FormData(target)).name
+		if (name === "") return
+
+		target.reset()
+		await post("/api/businessAccounts", { name })
+		await getData()
+	}
+
+	return (
+		<>
+			<h2 m-6 mb-0 flex flex-wrap gap-4 justify-between items-center>
+				<span inline-flex items-center>
+					<OpenCloseButton open={open} setOpen={setOpen} /> Business accounts
+				</span>
+			</h2>
+
+			{open ? (
+				<div p-4 flex flex-col gap-4>
+					{businessAccounts.map((account) => (
+						<Account key={account.ID} account={account} refresh={getData} />
+					))}
+					<form flex gap-2 onSubmit={onCreateAccount}>
+						<Input type="text" name="name" placeholder="New WABA name" />
+						<Button type="submit">Create WABA</Button>
+					</form>
+				</div>
+			) : undefined}
+		</>
+	)
+}
+
+interface AccountProps {
+	account: BusinessAccount
+	refresh: () => Promise<void>
+}
+
+function Account({ account, refresh }: AccountProps) {
+	const onCreatePhoneNumber = async (e: FormEvent<HTMLFormElement>) => {
+		e.preventDefault()
+
+		const target = e.target as HTMLFormElement
+		const phoneNumber = Object.fromEntries(new FormData(target)).phoneNumber
+		if (phoneNumber === "") return
+
+		target.reset()
+		await post(`/api/businessAccounts/${account.ID}/phoneNumbers`, {
+			phoneNumber,
+		})
+		await refresh()
+	}
+
+	return (
+		<div bg-zinc-900 rounded p-3>
+			<h4 m-0>
+				{account.name}{" "}
+				<span italic text-zinc-400>
+					(WABA id: {account.wabaId})
+				</span>
+			</h4>
+			{account.phoneNumbers.map((phoneNumber) => (
+				<div key={phoneNumber.ID} mt-2 text-sm>
+					<p m-0>
+						{phoneNumber.phoneNumber}{" "}
+						<span italic text-zinc-400>
+							(id: {phoneNumber.phoneNumberId})
+						</span>{" "}
+						<span text-zinc-400>
+							{phoneNumber.registrationStatus.toLowerCase()},{" "}
+							{phoneNumber.codeVerificationStatus.toLowerCase()}
+							{phoneNumber.verificationCode
+								? `, verification code: ${phoneNumber.verificationCode}`
+								: ""}
+						</span>
+					</p>
+					<Recipients phoneNumber={phoneNumber} refresh={refresh} />
+				</div>
+			))}
+			<form flex gap-2 mt-3 onSubmit={onCreatePhoneNumber}>
+				<Input type="text" name="phoneNumber" placeholder="+31600000000" />
+				<Button type="submit" variant="secondary">
+					Add phone number
+				</Button>
+			</form>
+		</div>
+	)
+}
+
+interface RecipientsProps {
+	phoneNumber: BusinessPhoneNumber
+	refresh: () => Promise<void>
+}
+
+const maxSandboxRecipients = 5
+
+function Recipients({ phoneNumber, refresh }: RecipientsProps) {
+	const sandbox = phoneNumber.recipientMode === "SANDBOX"
+	const allowedRecipients = phoneNumber.allowedRecipients ?? []
+
+	const update = async (
+		recipientMode: RecipientMode,
+		allowedRecipients: Array<string>,
+	) => {
+		await fetch(`/api/phoneNumbers/${phoneNumber.ID}/recipients`, {
+			method: "PUT",
+			headers: { "Content-Type": "application/json" },
+			body: JSON.stringify({ recipientMode, allowedRecipients }),
+		})
+		await refresh()
+	}
+
+	const onAddRecipient = async (e: FormEvent<HTMLFormElement>) => {
+		e.preventDefault()
+
+		const target = e.target as HTMLFormElement
+		const recipient = Object.fromEntries(new FormData(target)).recipient
+		if (!recipient) return
+
+		target.reset()
+		await update("SANDBOX", [...allowedRecipients, recipient as string])
+	}
+
+	return (
+		<div ml-4 mt-1 text-zinc-400>
+			<label flex items-center gap-2>
+				Recipients
+				<select
+					value={phoneNumber.recipientMode || "CONVERSATIONS"}
+					onChange={(e) =>
+						update(e.target.value as RecipientMode, allowedRecipients)
+					}
+				>
+					<option value="CONVERSATIONS">
+						Templates to everyone, other messages only to conversations
+					</option>
+					<option value="OPEN">Everyone</option>
+					<option value="SANDBOX">
+						Sandbox ({allowedRecipients.length}/{maxSandboxRecipients} allowed
+						recipients)
+					</option>
+				</select>
+			</label>
+			{sandbox ? (
+				<>
+					{allowedRecipients.map((recipient) => (
+						<p key={recipient} m-0 mt-1 flex items-center gap-2>
+							+{recipient}
+							<Button
+								size="sm"
+								variant="ghost"
+								onClick={() =>
+									update(
+										"SANDBOX",
+										allowedRecipients.filter((r) => r !== recipient),
+									)
+								}
+							>
+								Remove
+							</Button>
+						</p>
+					))}
+					{allowedRecipients.length < maxSandboxRecipients ? (
+						<form flex gap-2 mt-1 onSubmit={onAddRecipient}>
+							<Input
+								type="text"
+								name="recipient"
+								placeholder="Allowed recipient (+31600000000)"
+							/>
+							<Button type="submit" size="sm" variant="secondary">
+								Allow
+							</Button>
+						</form>
+					) : undefined}
+				</>
+			) : undefined}
+		</div>
+	)
+}
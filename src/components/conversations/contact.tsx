@@ -0,0 +1,96 @@
+import {
+	AlertDialog,
+	AlertDialogAction,
+	AlertDialogCancel,
+	AlertDialogContent,
+	AlertDialogFooter,
+	AlertDialogHeader,
+	AlertDialogTitle,
+} from "@/components/ui/alert-dialog"
+import { Input } from "@/components/ui/input"
+import { Label } from "@/components/ui/label"
+import { fetch } from "@/services/fetch"
+import { useEffect, useState } from "react"
+import { useConversationsStore, type Conversation } from "@/services/state"
+
+export interface ContactDialogProps {
+	open: boolean
+	conversation: Conversation
+	close: () => void
+}
+
+export function ContactDialog({
+	open,
+	conversation,
+	close,
+}: ContactDialogProps) {
+	const { updateConversation } = useConversationsStore()
+	const [state, setState] = useState({
+		contactName: conversation.contactName,
+		waId: conversation.waId ?? "",
+		hideProfileName: conversation.hideProfileName,
+	})
+
+	useEffect(() => {
+		if (!open) return
+		setState({
+			contactName: conversation.contactName,
+			waId: conversation.waId ?? "",
+			hideProfileName: conversation.hideProfileName,
+		})
+	}, [open])
+
+	const save = async () => {
+		const response = await fetch(`/api/conversations/${conversation.ID}`, {
+			method: "PATCH",
+			headers: { "Content-Type": "application/json" },
+			body: JSON.stringify(state),
+		})
+		updateConversation(await response.json())
+	}
+
+	return (
+		<AlertDialog open={open} onOpenChange={() => close()}>
+			<AlertDialogContent>
+				<AlertDialogHeader>
+					<AlertDialogTitle>
+						Contact {conversation.phoneNumber}
+					</AlertDialogTitle>
+				</AlertDialogHeader>
+				<Label htmlFor="contactName">Profile name</Label>
+				<Input
+					value={state.contactName}
+					onChange={(e) =>
+						setState((s) => ({ ...s, contactName: e.target.value }))
+					}
+					type="text"
+					id="contactName"
+					placeholder="John Doe"
+				/>
+				<Label htmlFor="waId">WhatsApp ID (wa_id)</Label>
+				<Input
+					value={state.waId}
+					onChange={(e) => setState((s) => ({ ...s, waId: e.target.value }))}
+					type="text"
+					id="waId"
+					placeholder={conversation.phoneNumber}
+				/>
+				<Label htmlFor="hideProfileName" flex items-center gap-2>
+					<input
+						checked={state.hideProfileName}
+						onChange={(e) =>
+							setState((s) => ({ ...s, hideProfileName: e.target.checked }))
+						}
+						type="checkbox"
+						id="hideProfileName"
+					/>
+					Omit the profile name from webhooks
+				</Label>
+				<AlertDialogFooter>
+					<AlertDialogCancel>Cancel</AlertDialogCancel>
+					<AlertDialogAction onClick={save}>Save</AlertDialogAction>
+				</AlertDialogFooter>
+			</AlertDialogContent>
+		</AlertDialog>
+	)
+}
@@ -1,8 +1,15 @@
 import { ShowMessage } from "./singleMessage"
+import { ContactDialog } from "./contact"
+import { Button } from "@/components/ui/button"
 import { Input } from "@/components/ui/input"
-import { post } from "@/services/fetch"
+import { getUrl, post } from "@/services/fetch"
 import { FormEvent, useEffect, useRef, useState } from "react"
-import { useConversationsStore, type Conversation } from "@/services/state"
+import {
+	findBusinessPhoneNumber,
+	useBusinessStore,
+	useConversationsStore,
+	type Conversation,
+} from "@/services/state"
 
 export interface ConversationProps {
 	conversation: Conversation
@@ -10,7 +17,13 @@ export interface ConversationProps {
 
 export function Conversation(props: ConversationProps) {
 	const { updateConversation } = useConversationsStore()
+	const { businessAccounts } = useBusinessStore()
+	const businessPhoneNumber = findBusinessPhoneNumber(
+		businessAccounts,
+		props.conversation.businessPhoneNumberId,
+	)
 	const [msgCount, setMsgCount] = useState(0)
+	const [contactOpen, setContactOpen] = useState(false)
 	const messagesEndRef = useRef<HTMLDivElement>(null)
 
 	const onSendMessage = async (e: FormEvent<HTMLFormElement>) => {
@@ -58,8 +71,57 @@ export function Conversation(props: ConversationProps) {
 				border-b-2
 				border-zinc-700
 				text-zinc-200
+				flex
+				justify-between
+				items-center
 			>
-				{props.conversation.phoneNumber}
+				<span flex items-center gap-3>
+					{businessPhoneNumber?.profile?.profilePicture ? (
+						<img
+							src={getUrl(
+								`/profile_pictures/${businessPhoneNumber.profile.profilePicture.sessionId}`,
+							)}
+							w-8
+							h-8
+							rounded-full
+							object-cover
+						/>
+					) : undefined}
+					<span>
+						{businessPhoneNumber ? (
+							<span block>
+								{businessPhoneNumber.verifiedName}{" "}
+								<span italic text-zinc-400>
+									({businessPhoneNumber.phoneNumber})
+								</span>
+							</span>
+						) : undefined}
+						{businessPhoneNumber?.profile?.about ? (
+							<span block text-xs text-zinc-400>
+								{businessPhoneNumber.profile.about}
+							</span>
+						) : undefined}
+						<span block text-xs text-zinc-400>
+							to {props.conversation.contactName} (
+							{props.conversation.phoneNumber})
+						</span>
+						{props.conversation.phoneNumberInfo ? (
+							<span block text-xs text-zinc-400>
+								{props.conversation.phoneNumberInfo.countryName},{" "}
+								{props.conversation.phoneNumberInfo.lineType === "MOBILE"
+									? "mobile"
+									: "fixed line"}
+							</span>
+						) : undefined}
+					</span>
+				</span>
+				<Button
+					size="sm"
+					variant="secondary"
+					onClick={() => setContactOpen(true)}
+				>
+					Contact
+				</Button>
 			</h4>
 			<div h-130 overflow-y-auto>
 				<div flex flex-col justify-end>
@@ -72,6 +134,11 @@ export function Conversation(props: ConversationProps) {
 			<form bg-zinc-700 onSubmit={onSendMessage}>
 				<Input type="text" name="message" placeholder="message" />
 			</form>
+			<ContactDialog
+				open={contactOpen}
+				conversation={props.conversation}
+				close={() => setContactOpen(false)}
+			/>
 		</div>
 	)
 }
@@ -1,5 +1,6 @@
 import { Button } from "@/components/ui/button"
 import { fetch } from "@/services/fetch"
+import { DuplicatesMode } from "./duplicates"
 import { useEffect, useState } from "react"
 import { OpenCloseButton } from "../openCloseButton"
 import { useConversationsStore } from "@/services/state"
@@ -27,16 +28,19 @@ export function Conversations() {
 				<span inline-flex items-center>
 					<OpenCloseButton open={open} setOpen={setOpen} /> Conversations
 				</span>
-				<Button onClick={() => setNewConversationOpen(true)}>
-					New conversation!
-				</Button>
+				<span inline-flex items-center gap-4>
+					<DuplicatesMode />
+					<Button onClick={() => setNewConversationOpen(true)}>
+						New conversation!
+					</Button>
+				</span>
 			</h2>
 			{conversations && open ? (
 				<div flex flex-wrap gap-4 p-4>
 					{conversations.map((conversation) => (
 						<Conversation
 							conversation={conversation}
-							key={conversation.phoneNumberId}
+							key={conversation.ID}
 						/>
 					))}
 				</div>
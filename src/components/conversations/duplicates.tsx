@@ -0,0 +1,49 @@
+import { fetch } from "@/services/fetch"
+import { useEffect, useState } from "react"
+
+type Mode = "off" | "flag" | "dedupe"
+
+interface DuplicateSettings {
+	mode: Mode
+	windowSeconds: number
+}
+
+export function DuplicatesMode() {
+	const [settings, setSettings] = useState<DuplicateSettings>({
+		mode: "off",
+		windowSeconds: 0,
+	})
+
+	const getData = async () => {
+		const response = await fetch("/api/duplicates/settings")
+		const settings: DuplicateSettings = await response.json()
+		setSettings({ ...settings, mode: settings.mode || "off" })
+	}
+
+	useEffect(() => {
+		getData()
+	}, [])
+
+	const setMode = async (mode: Mode) => {
+		const response = await fetch("/api/duplicates/settings", {
+			method: "PUT",
+			headers: { "Content-Type": "application/json" },
+			body: JSON.stringify({ ...settings, mode }),
+		})
+		setSettings(await response.json())
+	}
+
+	return (
+		<label text-sm font-normal flex items-center gap-2>
+			Duplicate sends
+			<select
+				value={settings.mode}
+				onChange={(e) => setMode(e.target.value as Mode)}
+			>
+				<option value="off">Not flagged</option>
+				<option value="flag">Flag</option>
+				<option value="dedupe">Deduplicate</option>
+			</select>
+		</label>
+	)
+}
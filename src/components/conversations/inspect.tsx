@@ -0,0 +1,48 @@
+import { useState } from "react"
+import { fetch } from "@/services/fetch"
+import type { Message, MessageInspection } from "@/services/state"
+import { Button } from "../ui/button"
+import { CodeBlock } from "../test/codeBlock"
+
+export function Inspect({ message }: { message: Message }) {
+	const [open, setOpen] = useState(false)
+	const [inspection, setInspection] = useState<MessageInspection>()
+
+	const toggle = async () => {
+		if (open) {
+			setOpen(false)
+			return
+		}
+		if (!inspection) {
+			const response = await fetch(`/api/messages/${message.ID}/inspect`)
+			setInspection(await response.json())
+		}
+		setOpen(true)
+	}
+
+	return (
+		<div flex flex-col items="end" style={{ maxWidth: "70%" }}>
+			<Button onClick={toggle} variant="link" size="sm">
+				{open ? "Hide request" : "Inspect"}
+			</Button>
+			{open && inspection ? (
+				<div p-3 rounded bg-zinc-900 w-full>
+					<p m-0 text-sm>
+						<span font-bold>{inspection.method}</span> {inspection.url}{" "}
+						<span text-zinc-400>(graph api {inspection.graphVersion})</span>
+					</p>
+					<h4 mb-0>Headers</h4>
+					<CodeBlock
+						code={Object.entries(inspection.headers)
+							.map(([name, value]) => `${name}: ${value}`)
+							.join("\n")}
+					/>
+					<h4 mb-0>Request</h4>
+					<CodeBlock code={JSON.stringify(inspection.request, null, 2)} />
+					<h4 mb-0>Response</h4>
+					<CodeBlock code={JSON.stringify(inspection.response, null, 2)} />
+				</div>
+			) : undefined}
+		</div>
+	)
+}
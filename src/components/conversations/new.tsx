@@ -11,7 +11,7 @@ import { Input } from "@/components/ui/input"
 import { Label } from "@/components/ui/label"
 import { post } from "@/services/fetch"
 import { useState } from "react"
-import type { Conversation } from "@/services/state"
+import { useBusinessStore, type Conversation } from "@/services/state"
 
 export interface NewChatDialogProps {
 	open: boolean
@@ -24,14 +24,21 @@ export function NewChatDialog({
 	open,
 	close,
 }: NewChatDialogProps) {
+	const { businessAccounts } = useBusinessStore()
+	const [businessPhoneNumberId, setBusinessPhoneNumberId] = useState(0)
 	const [state, setState] = useState({
 		message: "Hello world!",
 		phoneNumber: "",
+		contactName: "",
+		region: "",
 	})
 
 	const createConversation = async () => {
 		const response = await post("/api/conversations", {
+			businessPhoneNumberId,
 			phoneNumber: state.phoneNumber,
+			contactName: state.contactName,
+			region: state.region,
 			message: state.message,
 		})
 		const conversation = await response.json()
@@ -39,6 +46,8 @@ export function NewChatDialog({
 		setState({
 			message: "Hello world!",
 			phoneNumber: "",
+			contactName: "",
+			region: "",
 		})
 	}
 
@@ -48,6 +57,21 @@ export function NewChatDialog({
 				<AlertDialogHeader>
 					<AlertDialogTitle>Create a new conversation</AlertDialogTitle>
 				</AlertDialogHeader>
+				<Label htmlFor="businessPhoneNumber">Business phone number</Label>
+				<select
+					value={businessPhoneNumberId}
+					onChange={(e) => setBusinessPhoneNumberId(Number(e.target.value))}
+					id="businessPhoneNumber"
+				>
+					<option value={0}>Default</option>
+					{businessAccounts.map((account) =>
+						account.phoneNumbers.map((phoneNumber) => (
+							<option key={phoneNumber.ID} value={phoneNumber.ID}>
+								{account.name} - {phoneNumber.phoneNumber}
+							</option>
+						)),
+					)}
+				</select>
 				<Label htmlFor="phoneNumber">Source phone number</Label>
 				<Input
 					value={state.phoneNumber}
@@ -58,6 +82,24 @@ export function NewChatDialog({
 					id="phoneNumber"
 					placeholder="+31600000000"
 				/>
+				<Label htmlFor="region">Region of local phone numbers</Label>
+				<Input
+					value={state.region}
+					onChange={(e) => setState((s) => ({ ...s, region: e.target.value }))}
+					type="text"
+					id="region"
+					placeholder="Default (for example GB or DE)"
+				/>
+				<Label htmlFor="contactName">Contact name</Label>
+				<Input
+					value={state.contactName}
+					onChange={(e) =>
+						setState((s) => ({ ...s, contactName: e.target.value }))
+					}
+					type="text"
+					id="contactName"
+					placeholder="John Doe"
+				/>
 				<Label htmlFor="message">Message</Label>
 				<Input
 					value={state.message}
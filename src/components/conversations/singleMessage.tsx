@@ -5,6 +5,7 @@ import {
 } from "@/services/state"
 import { Button } from "../ui/button"
 import { post } from "@/services/fetch"
+import { Inspect } from "./inspect"
 
 function formatDate(date: Date) {
 	const dateFormatter = new Intl.DateTimeFormat("en-US", {
@@ -44,6 +45,18 @@ export function ShowMessage({ message }: { message: Message }) {
 						<Formatted text={message.headerMessage} />
 					</div>
 				) : undefined}
+				{message.duplicateOfId ? (
+					<div
+						inline-block
+						text-xs
+						px-1
+						rounded
+						bg-amber-700
+						title={`Identical to message #${message.duplicateOfId}`}
+					>
+						Duplicate
+					</div>
+				) : undefined}
 				<div>
 					<span text-xs>{formatDate(new Date(message.timestamp))} - </span>
 					{message.message
@@ -83,6 +96,7 @@ export function ShowMessage({ message }: { message: Message }) {
 					))}
 				</div>
 			) : undefined}
+			{message.hasInspection ? <Inspect message={message} /> : undefined}
 		</div>
 	)
 }
@@ -0,0 +1,147 @@
+import { Button } from "@/components/ui/button"
+import { Input } from "@/components/ui/input"
+import { fetch, post } from "@/services/fetch"
+import { FormEvent, useEffect, useState } from "react"
+import { OpenCloseButton } from "../openCloseButton"
+
+interface Fault {
+	id: number
+	recipient: string
+	type: "" | "text" | "template"
+	templateName: string
+	remaining: number
+	percentage: number
+	code: number
+	details: string
+	delayMs: number
+}
+
+interface CatalogEntry {
+	code: number
+	message: string
+}
+
+export function Faults() {
+	const [open, setOpen] = useState(false)
+	const [faults, setFaults] = useState<Array<Fault>>([])
+	const [catalog, setCatalog] = useState<Array<CatalogEntry>>([])
+
+	const getData = async () => {
+		const response = await fetch("/api/faults")
+		setFaults(await response.json())
+	}
+
+	const getCatalog = async () => {
+		const response = await fetch("/api/errors")
+		setCatalog(await response.json())
+	}
+
+	useEffect(() => {
+		getData()
+		getCatalog()
+	}, [])
+
+	const onCreateFault = async (e: FormEvent<HTMLFormElement>) => {
+		e.preventDefault()
+
+		const target = e.target as HTMLFormElement
+		const formData = new FormData(target)
+
+		await post("/api/faults", {
+			recipient: formData.get("recipient"),
+			type: formData.get("type"),
+			templateName: formData.get("templateName"),
+			remaining: Number(formData.get("remaining")),
+			percentage: Number(formData.get("percentage")),
+			code: Number(formData.get("code")),
+			details: formData.get("details"),
+			delayMs: Number(formData.get("delayMs")),
+		})
+		target.reset()
+		await getData()
+	}
+
+	const remove = async (fault: Fault) => {
+		await fetch(`/api/faults/${fault.id}`, { method: "DELETE" })
+		await getData()
+	}
+
+	return (
+		<>
+			<h2 m-6 mb-0 flex flex-wrap gap-4 justify-between items-center>
+				<span inline-flex items-center>
+					<OpenCloseButton open={open} setOpen={setOpen} /> Faults
+				</span>
+				<Button variant="secondary" onClick={getData}>
+					Refresh
+				</Button>
+			</h2>
+
+			{open ? (
+				<div p-4 flex flex-col gap-4>
+					{faults.map((fault) => (
+						<div key={fault.id} bg-zinc-900 rounded p-3 flex gap-4>
+							<div flex-1>
+								<h4 m-0>
+									{fault.code ? `Error #${fault.code}` : "No error"}
+									{fault.delayMs ? `, ${fault.delayMs}ms delay` : ""}
+								</h4>
+								<p m-0 mt-2 text-sm text-zinc-400>
+									{fault.recipient ? `To +${fault.recipient}` : "All recipients"}
+									{fault.type ? `, ${fault.type} messages` : ", all messages"}
+									{fault.templateName ? `, template ${fault.templateName}` : ""}
+									{fault.remaining
+										? `, next ${fault.remaining} messages`
+										: ", every message"}
+									{fault.percentage ? `, ${fault.percentage}% chance` : ""}
+								</p>
+							</div>
+							<Button size="sm" variant="ghost" onClick={() => remove(fault)}>
+								Delete
+							</Button>
+						</div>
+					))}
+					<form flex flex-wrap items-center gap-2 onSubmit={onCreateFault}>
+						<Input type="text" name="recipient" placeholder="Recipient (all)" />
+						<select name="type" defaultValue="">
+							<option value="">All types</option>
+							<option value="text">Text</option>
+							<option value="template">Template</option>
+						</select>
+						<Input
+							type="text"
+							name="templateName"
+							placeholder="Template name (all)"
+						/>
+						<Input
+							type="number"
+							name="remaining"
+							placeholder="Messages (0 = all)"
+						/>
+						<Input
+							type="number"
+							name="percentage"
+							placeholder="Percentage (0 = always)"
+						/>
+						<Input
+							type="number"
+							name="code"
+							placeholder="Error code (131026)"
+							list="error-codes"
+						/>
+						<datalist id="error-codes">
+							{catalog.map((entry) => (
+								<option key={entry.code} value={entry.code}>
+									{entry.message}
+								</option>
+							))}
+						</datalist>
+						<Input type="text" name="details" placeholder="Error details" />
+						<Input type="number" name="delayMs" placeholder="Delay (ms)" />
+						<Button type="submit">Add fault</Button>
+					</form>
+				</div>
+			) : undefined}
+		</>
+	)
+}
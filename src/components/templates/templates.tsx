@@ -16,8 +16,10 @@ import { DBModel, emptyDBModel } from "@/lib/types"
 import { TrashIcon } from "@radix-ui/react-icons"
 import { OpenCloseButton } from "../openCloseButton"
 import { Textarea } from "@/components/ui/textarea"
+import { useBusinessStore } from "@/services/state"
 
 interface Template extends DBModel {
+	businessAccountId: number
 	name: string
 	header: string | null
 	body: string
@@ -110,6 +112,11 @@ interface TemplateProps {
 }
 
 function Template({ template, remove }: TemplateProps) {
+	const { businessAccounts } = useBusinessStore()
+	const businessAccount = businessAccounts.find(
+		(account) => account.ID === template.businessAccountId,
+	)
+
 	return (
 		<div flex w-full gap-4>
 			<div>
@@ -118,7 +125,14 @@ function Template({ template, remove }: TemplateProps) {
 				</Button>
 			</div>
 			<div overflow-hidden>
-				<h4>{template.name}</h4>
+				<h4>
+					{template.name}{" "}
+					{businessAccount ? (
+						<span italic text-zinc-400>
+							({businessAccount.name})
+						</span>
+					) : undefined}
+				</h4>
 				<p truncate text-sm text-zinc-400>
 					{template.body} {template.body}
 				</p>
@@ -155,6 +169,7 @@ interface NewTemplateDialogProps {
 
 const emptyTemplate = (): Template => ({
 	...emptyDBModel(),
+	businessAccountId: 0,
 	name: "hello_world_2",
 	header: null,
 	body: "",
@@ -168,6 +183,7 @@ function NewTemplateDialog({
 	close,
 }: NewTemplateDialogProps) {
 	const [state, setState] = useState<Template>(emptyTemplate())
+	const { businessAccounts } = useBusinessStore()
 
 	const createConversation = async () => {
 		const response = await post("/api/templates", state)
@@ -215,6 +231,24 @@ function NewTemplateDialog({
 				<AlertDialogHeader>
 					<AlertDialogTitle>Create a new conversation</AlertDialogTitle>
 				</AlertDialogHeader>
+				<Label htmlFor="businessAccount">Business account</Label>
+				<select
+					value={state.businessAccountId}
+					onChange={(e) =>
+						setState((s) => ({
+							...s,
+							businessAccountId: Number(e.target.value),
+						}))
+					}
+					id="businessAccount"
+				>
+					<option value={0}>Default</option>
+					{businessAccounts.map((account) => (
+						<option key={account.ID} value={account.ID}>
+							{account.name}
+						</option>
+					))}
+				</select>
 				<Label htmlFor="header">Name</Label>
 				<Input
 					value={state.name}
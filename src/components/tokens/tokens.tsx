@@ -0,0 +1,142 @@
+import { Button } from "@/components/ui/button"
+import { Input } from "@/components/ui/input"
+import { fetch, post } from "@/services/fetch"
+import { DBModel } from "@/lib/types"
+import { FormEvent, useEffect, useState } from "react"
+import { OpenCloseButton } from "../openCloseButton"
+
+interface AccessToken extends DBModel {
+	name: string
+	token: string
+	type: "USER" | "SYSTEM_USER"
+	scopes: Array<string>
+	expiresAt: string | null
+	revokedAt: string | null
+	revokeReason: string | null
+}
+
+const allScopes = [
+	"whatsapp_business_messaging",
+	"whatsapp_business_management",
+]
+
+export function Tokens() {
+	const [open, setOpen] = useState(false)
+	const [tokens, setTokens] = useState<Array<AccessToken>>([])
+
+	const getData = async () => {
+		const response = await fetch("/api/tokens")
+		setTokens(await response.json())
+	}
+
+	useEffect(() => {
+		getData()
+	}, [])
+
+	const onCreateToken = async (e: FormEvent<HTMLFormElement>) => {
+		e.preventDefault()
+
+		const target = e.target as HTMLFormElement
+		const formData = new FormData(target)
+		const name = formData.get("name")
+		if (!name) return
+
+		await post("/api/tokens", {
+			name,
+			type: formData.get("type"),
+			scopes: allScopes.filter((scope) => formData.get(scope)),
+			expiresIn: Number(formData.get("expiresIn")),
+		})
+		target.reset()
+		await getData()
+	}
+
+	const revoke = async (token: AccessToken, reason: string) => {
+		await post(`/api/tokens/${token.ID}/revoke`, { reason })
+		await getData()
+	}
+
+	const remove = async (token: AccessToken) => {
+		await fetch(`/api/tokens/${token.ID}`, { method: "DELETE" })
+		await getData()
+	}
+
+	return (
+		<>
+			<h2 m-6 mb-0 flex flex-wrap gap-4 justify-between items-center>
+				<span inline-flex items-center>
+					<OpenCloseButton open={open} setOpen={setOpen} /> Access tokens
+				</span>
+			</h2>
+
+			{open ? (
+				<div p-4 flex flex-col gap-4>
+					{tokens.map((token) => (
+						<div key={token.ID} bg-zinc-900 rounded p-3>
+							<h4 m-0>
+								{token.name}{" "}
+								<span italic text-zinc-400>
+									({token.type.toLowerCase()}, {token.scopes.join(", ")})
+								</span>
+							</h4>
+							<p m-0 mt-2 text-sm text-zinc-400 break-all>
+								{token.token}
+							</p>
+							<p m-0 mt-2 text-sm>
+								{token.revokedAt
+									? `Revoked (${token.revokeReason})`
+									: token.expiresAt
+										? `Expires at ${new Date(token.expiresAt).toLocaleString()}`
+										: "Never expires"}
+							</p>
+							<div flex gap-2 mt-2>
+								{token.revokedAt ? undefined : (
+									<>
+										<Button
+											size="sm"
+											variant="secondary"
+											onClick={() => revoke(token, "invalidated")}
+										>
+											Revoke
+										</Button>
+										<Button
+											size="sm"
+											variant="secondary"
+											onClick={() => revoke(token, "password_changed")}
+										>
+											Change password
+										</Button>
+									</>
+								)}
+								{token.name === "Default" ? undefined : (
+									<Button size="sm" variant="ghost" onClick={() => remove(token)}>
+										Delete
+									</Button>
+								)}
+							</div>
+						</div>
+					))}
+					<form flex flex-wrap items-center gap-2 onSubmit={onCreateToken}>
+						<Input type="text" name="name" placeholder="Token name" />
+						<select name="type" defaultValue="SYSTEM_USER">
+							<option value="SYSTEM_USER">System user</option>
+							<option value="USER">User</option>
+						</select>
+						{allScopes.map((scope) => (
+							<label key={scope} text-sm flex items-center gap-1>
+								<input type="checkbox" name={scope} defaultChecked />
+								{scope}
+							</label>
+						))}
+						<Input
+							type="number"
+							name="expiresIn"
+							placeholder="Expires in (seconds, 0 = never)"
+						/>
+						<Button type="submit">Create token</Button>
+					</form>
+				</div>
+			) : undefined}
+		</>
+	)
+}
@@ -3,15 +3,20 @@ import { Fragment, useEffect, useState } from "react"
 import { Conversations } from "@/components/conversations/conversations"
 import { Templates } from "@/components/templates/templates"
 import { Test } from "@/components/test/test"
+import { BusinessAccounts } from "@/components/business/business"
+import { Tokens } from "@/components/tokens/tokens"
+import { Faults } from "@/components/faults/faults"
 import { State, useConversationsStore } from "@/services/state"
 import { EventsWebsocket } from "@/services/websocket"
 
 export function App() {
 	const [state, setState] = useState<State>({
 		graphToken: "",
+		appID: "",
 		appSecret: "",
 		phoneNumber: "",
 		phoneNumberID: "",
+		businessAccountID: "",
 		webhookURL: "",
 	})
 
@@ -38,6 +43,12 @@ export function App() {
 
 			<Test state={state} />
 
+			<BusinessAccounts />
+
+			<Tokens />
+
+			<Faults />
+
 			<Templates />
 
 			<Conversations />
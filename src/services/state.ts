@@ -3,18 +3,74 @@ import { create } from "zustand"
 
 export interface State {
 	graphToken: string
+	appID: string
 	appSecret: string
 	phoneNumber: string
 	phoneNumberID: string
+	businessAccountID: string
 	webhookURL: string
 }
 
+export interface BusinessAccount extends DBModel {
+	wabaId: string
+	name: string
+	phoneNumbers: Array<BusinessPhoneNumber>
+}
+
+export type RecipientMode = "" | "CONVERSATIONS" | "OPEN" | "SANDBOX"
+
+export interface BusinessPhoneNumber extends DBModel {
+	businessAccountId: number
+	phoneNumber: string
+	phoneNumberId: string
+	verifiedName: string
+	qualityRating: string
+	registrationStatus: "REGISTERED" | "UNREGISTERED"
+	codeVerificationStatus: "NOT_VERIFIED" | "VERIFIED" | "EXPIRED"
+	verificationCode: string | null
+	twoStepPin: string | null
+	profile: BusinessProfile | null
+	recipientMode: RecipientMode
+	allowedRecipients: Array<string> | null
+}
+
+export interface BusinessProfile extends DBModel {
+	about: string
+	address: string
+	description: string
+	email: string
+	websites: Array<string> | null
+	vertical: string
+	profilePicture: Upload | null
+}
+
+export interface Upload extends DBModel {
+	sessionId: string
+	fileName: string
+	fileType: string
+	fileLength: number
+	handle: string | null
+}
+
 export interface Conversation extends DBModel {
+	businessPhoneNumberId: number
 	phoneNumberId: string
 	phoneNumber: string
+	contactName: string
+	waId: string | null
+	hideProfileName: boolean
+	phoneNumberInfo: PhoneNumberInfo | null
 	messages: Array<Message>
 }
 
+export interface PhoneNumberInfo {
+	e164: string
+	country: string
+	countryName: string
+	countryCode: string
+	lineType: "MOBILE" | "FIXED_LINE"
+}
+
 export interface Message extends DBModel {
 	conversationId: number
 	whatsappID: string
@@ -24,6 +80,19 @@ export interface Message extends DBModel {
 	headerMessage: string
 	timestamp: number
 	buttons: null | Array<MessageButton>
+	duplicateOfId: number | null
+	templateName: string | null
+	hasInspection: boolean
+}
+
+export interface MessageInspection {
+	messageId: number
+	graphVersion: string
+	method: string
+	url: string
+	headers: Record<string, string>
+	request: unknown
+	response: unknown
 }
 
 export interface MessageButton extends DBModel {
@@ -31,6 +100,30 @@ export interface MessageButton extends DBModel {
 	payload: string
 }
 
+interface BusinessState {
+	businessAccounts: Array<BusinessAccount>
+	setBusinessAccounts: (businessAccounts: Array<BusinessAccount>) => void
+}
+
+export const useBusinessStore = create<BusinessState>((set) => ({
+	businessAccounts: [],
+	setBusinessAccounts(businessAccounts) {
+		set((state) => ({ ...state, businessAccounts }))
+	},
+}))
+
+export function findBusinessPhoneNumber(
+	businessAccounts: Array<BusinessAccount>,
+	id: number,
+): BusinessPhoneNumber | undefined {
+	for (const account of businessAccounts) {
+		for (const phoneNumber of account.phoneNumbers) {
+			if (phoneNumber.ID === id) return phoneNumber
+		}
+	}
+	return undefined
+}
+
 interface ConversationsState {
 	conversations: Array<Conversation>
 	setConversations: (conversations: Array<Conversation>) => void
@@ -49,7 +142,7 @@ export const useConversationsStore = create<ConversationsState>((set) => ({
 			for (let idx = 0; idx < state.conversations.length; idx++) {
 				const conversationNeedle = state.conversations[idx]
 
-				if (conversation.phoneNumberId === conversationNeedle.phoneNumberId) {
+				if (conversation.ID === conversationNeedle.ID) {
 					const conversations = [...state.conversations]
 					conversations[idx] = conversation
 					return {
@@ -0,0 +1,318 @@
+// Package whatsappdev runs whatsapp-dev inside Go tests.
+//
+//	func TestBot(t *testing.T) {
+//		server := whatsappdev.NewTestServer(t, whatsappdev.Options{WebhookURL: myApp.URL + "/webhook"})
+//		myApp.SetGraphURL(server.URL, server.GraphToken, server.PhoneNumberID)
+//
+//		c := server.Client()
+//		conversation, err := c.StartConversation(ctx, client.StartConversationOptions{PhoneNumber: "31612345678", Message: "Hi"})
+//		...
+//	}
+package whatsappdev
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mjarkk/whatsapp-dev/client"
+	src "github.com/mjarkk/whatsapp-dev/go"
+	"github.com/mjarkk/whatsapp-dev/go/db"
+	"github.com/mjarkk/whatsapp-dev/go/utils/random"
+)
+
+// Options are the options of a test server, all values are optional
+type Options struct {
+	// Seed is the secrets seed used to generate the tokens and ids that are not set, defaults to the name of the test
+	Seed               string
+	GraphToken         string
+	AppID              string
+	AppSecret          string
+	PhoneNumber        string
+	PhoneNumberID      string
+	BusinessAccountID  string
+	WebhookVerifyToken string
+	// WebhookURL receives the webhooks of the test server, if empty a webhook receiver is started that records all webhooks, see TestServer.Webhooks
+	WebhookURL         string
+	WebhookBatchWindow time.Duration
+	// WebhookStatuses sends the sent and delivered statuses of messages sent by the business to the webhook
+	WebhookStatuses bool
+	// DefaultRegion is used to parse local phone numbers, defaults to NL
+	DefaultRegion string
+	// EnableWaIDQuirks enables the Brazilian and Mexican wa_id normalization
+	EnableWaIDQuirks bool
+}
+
+// TestServer is a whatsapp-dev instance running within a test
+type TestServer struct {
+	// URL is the base url of the test server, it serves both the graph api and the admin api (/api)
+	URL                string
+	GraphToken         string
+	AppID              string
+	AppSecret          string
+	PhoneNumber        string
+	PhoneNumberID      string
+	BusinessAccountID  string
+	WebhookURL         string
+	WebhookVerifyToken string
+
+	process       *exec.Cmd
+	processStdin  io.WriteCloser
+	processExited chan struct{}
+	webhookServer *httptest.Server
+	webhooksLock  sync.Mutex
+	webhooks      []json.RawMessage
+	closeOnce     sync.Once
+	clientsLock   sync.Mutex
+	clients       []*client.Client
+}
+
+// processEnv contains the settings of a test server process, see NewTestServer
+const processEnv = "WHATSAPPDEV_TEST_SERVER"
+
+// listeningPrefix prefixes the address of a test server process on its stdout
+const listeningPrefix = "whatsappdev listening on "
+
+// processSettings are the settings send from the test to its test server process
+type processSettings struct {
+	Seed    string  `json:"seed"`
+	Options Options `json:"options"`
+}
+
+// whatsapp-dev keeps its database and settings in package globals, every test server thus runs in its own process.
+// The test binary is started again with processEnv set and runs the test server instead of the tests.
+func init() {
+	settings, ok := os.LookupEnv(processEnv)
+	if !ok {
+		return
+	}
+
+	err := runProcess(settings)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "whatsappdev:", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+// runProcess runs a test server until stdin is closed by the test
+func runProcess(rawSettings string) error {
+	settings := processSettings{}
+	err := json.Unmarshal([]byte(rawSettings), &settings)
+	if err != nil {
+		return err
+	}
+	opts := settings.Options
+
+	// Continue with the same random source as NewTestServer so the seeded values match
+	r := random.SeededSource(settings.Seed)
+	random.GetRandomValuesForSetup(r)
+
+	err = db.ConnectToMemoryDatabase()
+	if err != nil {
+		return errors.New("unable to create database: " + err.Error())
+	}
+
+	err = src.Setup(src.SetupOptions{
+		GraphToken:         opts.GraphToken,
+		AppID:              opts.AppID,
+		AppSecret:          opts.AppSecret,
+		PhoneNumber:        opts.PhoneNumber,
+		PhoneNumberID:      opts.PhoneNumberID,
+		BusinessAccountID:  opts.BusinessAccountID,
+		WebhookURL:         opts.WebhookURL,
+		WebhookVerifyToken: opts.WebhookVerifyToken,
+		WebhookBatchWindow: opts.WebhookBatchWindow,
+		WebhookStatuses:    opts.WebhookStatuses,
+		DefaultRegion:      opts.DefaultRegion,
+		WaIDQuirks:         opts.EnableWaIDQuirks,
+		WamidSeed:          r.Int63(),
+		IDSeed:             r.Int63(),
+	})
+	if err != nil {
+		return errors.New("unable to setup test server: " + err.Error())
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return errors.New("unable to listen: " + err.Error())
+	}
+	app := src.NewApp(src.StartWebserverOptions{
+		Rand:          r,
+		DisableLogger: true,
+	})
+	go app.Listener(listener)
+	fmt.Println(listeningPrefix + listener.Addr().String())
+
+	// Stdin is closed by TestServer.Close or when the test binary exits
+	io.Copy(io.Discard, os.Stdin)
+	return app.ShutdownWithTimeout(5 * time.Second)
+}
+
+// NewTestServer starts a whatsapp-dev instance with an empty in memory database that is closed when the test finishes.
+//
+// Every test server runs in its own process (the test binary started again), so test servers are fully isolated
+// and can run at the same time, also within one test and in parallel tests.
+func NewTestServer(t testing.TB, opts Options) *TestServer {
+	t.Helper()
+
+	s := &TestServer{processExited: make(chan struct{})}
+	t.Cleanup(s.Close)
+
+	seed := opts.Seed
+	if seed == "" {
+		seed = t.Name()
+	}
+	randomValues := random.GetRandomValuesForSetup(random.SeededSource(seed))
+	s.GraphToken = valueOr(opts.GraphToken, randomValues.GraphToken)
+	s.AppID = valueOr(opts.AppID, randomValues.AppID)
+	s.AppSecret = valueOr(opts.AppSecret, randomValues.AppSecret)
+	s.PhoneNumber = valueOr(opts.PhoneNumber, randomValues.PhoneNumber)
+	s.PhoneNumberID = valueOr(opts.PhoneNumberID, randomValues.PhoneNumberID)
+	s.BusinessAccountID = valueOr(opts.BusinessAccountID, randomValues.BusinessAccountID)
+	s.WebhookVerifyToken = valueOr(opts.WebhookVerifyToken, randomValues.WebhookVerifyToken)
+
+	s.WebhookURL = opts.WebhookURL
+	if s.WebhookURL == "" {
+		s.webhookServer = httptest.NewServer(http.HandlerFunc(s.receiveWebhook))
+		s.WebhookURL = s.webhookServer.URL
+	}
+
+	opts.GraphToken = s.GraphToken
+	opts.AppID = s.AppID
+	opts.AppSecret = s.AppSecret
+	opts.PhoneNumber = s.PhoneNumber
+	opts.PhoneNumberID = s.PhoneNumberID
+	opts.BusinessAccountID = s.BusinessAccountID
+	opts.WebhookVerifyToken = s.WebhookVerifyToken
+	opts.WebhookURL = s.WebhookURL
+	opts.DefaultRegion = valueOr(opts.DefaultRegion, "NL")
+	settings, err := json.Marshal(processSettings{Seed: seed, Options: opts})
+	if err != nil {
+		t.Fatal("whatsappdev: unable to encode the test server settings:", err)
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		t.Fatal("whatsappdev: unable to find the test binary:", err)
+	}
+	s.process = exec.Command(executable)
+	s.process.Env = append(os.Environ(), processEnv+"="+string(settings))
+	s.process.Stderr = os.Stderr
+	s.processStdin, err = s.process.StdinPipe()
+	if err != nil {
+		t.Fatal("whatsappdev: unable to start test server:", err)
+	}
+	stdout, err := s.process.StdoutPipe()
+	if err != nil {
+		t.Fatal("whatsappdev: unable to start test server:", err)
+	}
+	err = s.process.Start()
+	if err != nil {
+		t.Fatal("whatsappdev: unable to start test server:", err)
+	}
+	go func() {
+		s.process.Wait()
+		close(s.processExited)
+	}()
+
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		address, ok := strings.CutPrefix(scanner.Text(), listeningPrefix)
+		if ok {
+			s.URL = "http://" + address
+			break
+		}
+	}
+	if s.URL == "" {
+		t.Fatal("whatsappdev: the test server exited before it started listening, see the output above")
+		return nil
+	}
+	go io.Copy(io.Discard, stdout)
+
+	return s
+}
+func valueOr(value string, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+// Client returns a client for the admin api of the test server, the client is closed together with the test server
+func (s *TestServer) Client() *client.Client {
+	c := client.New(s.URL)
+
+	s.clientsLock.Lock()
+	s.clients = append(s.clients, c)
+	s.clientsLock.Unlock()
+
+	return c
+}
+
+// Webhooks returns the bodies of all webhooks received by the built-in webhook receiver.
+// Note that like the real api webhooks are delivered with a random delay and are sometimes delivered more than once.
+func (s *TestServer) Webhooks() []json.RawMessage {
+	s.webhooksLock.Lock()
+	defer s.webhooksLock.Unlock()
+
+	return append([]json.RawMessage{}, s.webhooks...)
+}
+
+func (s *TestServer) receiveWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		query := r.URL.Query()
+		if query.Get("hub.verify_token") != s.WebhookVerifyToken {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(query.Get("hub.challenge")))
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil || !json.Valid(body) {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	s.webhooksLock.Lock()
+	s.webhooks = append(s.webhooks, body)
+	s.webhooksLock.Unlock()
+}
+
+// Close stops the test server, it's called automatically when the test finishes
+func (s *TestServer) Close() {
+	s.closeOnce.Do(func() {
+		s.clientsLock.Lock()
+		for _, c := range s.clients {
+			c.Close()
+		}
+		s.clientsLock.Unlock()
+
+		if s.process != nil && s.process.Process != nil {
+			// Closing stdin shuts down the test server, the pending webhooks and bot actions stop with its process
+			s.processStdin.Close()
+			select {
+			case <-s.processExited:
+			case <-time.After(10 * time.Second):
+				s.process.Process.Kill()
+				<-s.processExited
+			}
+		}
+		if s.webhookServer != nil {
+			s.webhookServer.Close()
+		}
+	})
+}
@@ -0,0 +1,355 @@
+package whatsappdev
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mjarkk/whatsapp-dev/client"
+)
+
+func TestTwoTestServersInSequence(t *testing.T) {
+	ctx := context.Background()
+
+	first := NewTestServer(t, Options{WebhookBatchWindow: time.Second})
+	_, err := first.Client().StartConversation(ctx, client.StartConversationOptions{PhoneNumber: "+31612345678", Message: "Hi"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Closing the first test server before the batch window has passed drops its pending webhook
+	first.Close()
+
+	second := NewTestServer(t, Options{})
+	if second.URL == first.URL {
+		t.Fatal("expected the second test server to listen on another url")
+	}
+
+	conversations, err := second.Client().Conversations(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(conversations) != 0 {
+		t.Fatalf("expected the second test server to start without conversations, got %d", len(conversations))
+	}
+
+	// Wait for the batch window and the random webhook delay of the first test server
+	time.Sleep(3 * time.Second)
+	if webhooks := first.Webhooks(); len(webhooks) != 0 {
+		t.Fatalf("expected the pending webhook of the first test server to be dropped, got %s", webhooks)
+	}
+	if webhooks := second.Webhooks(); len(webhooks) != 0 {
+		t.Fatalf("expected no webhooks of the first test server at the second test server, got %s", webhooks)
+	}
+}
+
+func TestTestServersAreIsolated(t *testing.T) {
+	ctx := context.Background()
+
+	first := NewTestServer(t, Options{})
+	second := NewTestServer(t, Options{Seed: "second"})
+
+	_, err := first.Client().StartConversation(ctx, client.StartConversationOptions{PhoneNumber: "+31612345678", Message: "Hi"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conversations, err := second.Client().Conversations(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(conversations) != 0 {
+		t.Fatalf("expected the conversation of the first test server to not exist at the second one, got %d", len(conversations))
+	}
+	conversations, err = first.Client().Conversations(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(conversations) != 1 {
+		t.Fatalf("expected 1 conversation at the first test server, got %d", len(conversations))
+	}
+}
+
+func TestParallelTestServers(t *testing.T) {
+	for _, phoneNumber := range []string{"+31612345678", "+31687654321", "+31611112222"} {
+		phoneNumber := phoneNumber
+		t.Run(phoneNumber, func(t *testing.T) {
+			t.Parallel()
+
+			server := NewTestServer(t, Options{})
+			c := server.Client()
+			ctx := context.Background()
+
+			conversation, err := c.StartConversation(ctx, client.StartConversationOptions{PhoneNumber: phoneNumber, Message: "Hi"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			postJSON(t, server.URL+"/v19.0/"+server.PhoneNumberID+"/messages", server.GraphToken, `{"messaging_product":"whatsapp","to":"`+phoneNumber+`","type":"text","text":{"body":"Hello `+phoneNumber+`"}}`)
+
+			message, err := c.WaitForMessage(ctx, client.InConversation(conversation.ID))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if message.Message != "Hello "+phoneNumber {
+				t.Fatalf("expected the message of this test, got %q", message.Message)
+			}
+
+			conversations, err := c.Conversations(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(conversations) != 1 {
+				t.Fatalf("expected only the conversation of this test, got %d", len(conversations))
+			}
+		})
+	}
+}
+
+func TestWaitForMessageIgnoresUserMessages(t *testing.T) {
+	server := NewTestServer(t, Options{})
+	c := server.Client()
+	ctx := context.Background()
+
+	conversation, err := c.StartConversation(ctx, client.StartConversationOptions{PhoneNumber: "+31612345678", Message: "Hi"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The reply of the bot is a message of the user that is also send over the events websocket
+	postJSON(t, server.URL+"/api/bots", "", `{"name":"Echo","rules":[{"when":{},"then":{"reply":"Thanks"}}]}`)
+	postJSON(t, server.URL+"/v19.0/"+server.PhoneNumberID+"/messages", server.GraphToken, `{"messaging_product":"whatsapp","to":"+31612345678","type":"text","text":{"body":"Hello"}}`)
+
+	message, err := c.WaitForMessage(ctx, client.InConversation(conversation.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if message.Message != "Hello" {
+		t.Fatalf("expected the business message, got %q", message.Message)
+	}
+
+	// Wait until the bot replied
+	for idx := 0; ; idx++ {
+		conversation, err = c.Conversation(ctx, conversation.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if conversation.LastMessage().Message == "Thanks" {
+			break
+		}
+		if idx == 50 {
+			t.Fatal("expected the bot to reply")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, 500*time.Millisecond)
+	defer cancel()
+	message, err = c.WaitForMessage(waitCtx, nil)
+	if err != context.DeadlineExceeded {
+		t.Fatalf("expected no business message, got %+v (error %v)", message, err)
+	}
+}
+
+func postJSON(t *testing.T, url string, token string, body string) {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= 300 {
+		responseBody, _ := io.ReadAll(res.Body)
+		t.Fatalf("POST %s: status %d: %s", url, res.StatusCode, responseBody)
+	}
+}
+
+func TestResetStopsWaitingForMessages(t *testing.T) {
+	server := NewTestServer(t, Options{})
+
+	status := make(chan int, 1)
+	go func() {
+		res, err := http.Get(server.URL + "/api/messages/wait?timeout=10s")
+		if err != nil {
+			status <- 0
+			return
+		}
+		res.Body.Close()
+		status <- res.StatusCode
+	}()
+
+	// Give the request time to start waiting
+	time.Sleep(200 * time.Millisecond)
+	err := server.Client().Reset(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case code := <-status:
+		if code != http.StatusConflict {
+			t.Fatalf("expected status %d, got %d", http.StatusConflict, code)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the reset to stop the waiting request")
+	}
+}
+
+func TestVersionedFieldIsHiddenBeforeItsVersion(t *testing.T) {
+	server := NewTestServer(t, Options{})
+
+	get := func(version string) (int, string) {
+		req, err := http.NewRequest(http.MethodGet, server.URL+"/"+version+"/"+server.PhoneNumberID+"?fields=health_status", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Authorization", "Bearer "+server.GraphToken)
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+		body, err := io.ReadAll(res.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res.StatusCode, string(body)
+	}
+
+	code, body := get("v18.0")
+	if code != http.StatusBadRequest || !strings.Contains(body, "Tried accessing nonexisting field (health_status)") {
+		t.Fatalf("expected health_status to not exist in v18.0, got status %d: %s", code, body)
+	}
+
+	for _, version := range []string{"v19.0", "v20.0"} {
+		code, body = get(version)
+		if code != http.StatusOK || !strings.Contains(body, `"health_status":{`) {
+			t.Fatalf("expected health_status in %s, got status %d: %s", version, code, body)
+		}
+	}
+}
+
+func TestStatusWebhooksAreOptIn(t *testing.T) {
+	// statuses returns the statuses in the received webhooks, a status can be received more than once because of the forced retries
+	statuses := func(server *TestServer) [][]string {
+		result := [][]string{}
+		for _, body := range server.Webhooks() {
+			payload := struct {
+				Entry []struct {
+					Changes []struct {
+						Value struct {
+							Statuses []struct {
+								Status string `json:"status"`
+							} `json:"statuses"`
+						} `json:"value"`
+					} `json:"changes"`
+				} `json:"entry"`
+			}{}
+			err := json.Unmarshal(body, &payload)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, entry := range payload.Entry {
+				for _, change := range entry.Changes {
+					if len(change.Value.Statuses) == 0 {
+						continue
+					}
+					sequence := []string{}
+					for _, status := range change.Value.Statuses {
+						sequence = append(sequence, status.Status)
+					}
+					result = append(result, sequence)
+				}
+			}
+		}
+		return result
+	}
+
+	send := func(t *testing.T, server *TestServer) {
+		_, err := server.Client().StartConversation(context.Background(), client.StartConversationOptions{PhoneNumber: "+31612345678", Message: "Hi"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		postJSON(t, server.URL+"/v19.0/"+server.PhoneNumberID+"/messages", server.GraphToken, `{"messaging_product":"whatsapp","to":"+31612345678","type":"text","text":{"body":"Hello"}}`)
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		t.Parallel()
+
+		server := NewTestServer(t, Options{})
+		send(t, server)
+
+		// Wait for the random webhook delay
+		time.Sleep(2 * time.Second)
+		if sequences := statuses(server); len(sequences) != 0 {
+			t.Fatalf("expected no status webhooks, got %v", sequences)
+		}
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		t.Parallel()
+
+		// The batch window combines the statuses of the message into one change so their order is fixed
+		server := NewTestServer(t, Options{WebhookStatuses: true, WebhookBatchWindow: 200 * time.Millisecond})
+		send(t, server)
+
+		for idx := 0; ; idx++ {
+			sequences := statuses(server)
+			if len(sequences) > 0 {
+				if !reflect.DeepEqual(sequences[0], []string{"sent", "delivered"}) {
+					t.Fatalf("expected the statuses sent and delivered, got %v", sequences[0])
+				}
+				break
+			}
+			if idx == 50 {
+				t.Fatal("expected a status webhook")
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+	})
+}
+
+func TestSendResponseContainsTheInput(t *testing.T) {
+	server := NewTestServer(t, Options{})
+	_, err := server.Client().StartConversation(context.Background(), client.StartConversationOptions{PhoneNumber: "+31612345678", Message: "Hi"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, server.URL+"/v19.0/"+server.PhoneNumberID+"/messages", strings.NewReader(`{"messaging_product":"whatsapp","to":"31612345678","type":"text","text":{"body":"Hello"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+server.GraphToken)
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	response := struct {
+		Contacts []struct {
+			Input string `json:"input"`
+			WaID  string `json:"wa_id"`
+		} `json:"contacts"`
+	}{}
+	err = json.NewDecoder(res.Body).Decode(&response)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(response.Contacts) != 1 || response.Contacts[0].Input != "31612345678" || response.Contacts[0].WaID != "31612345678" {
+		t.Fatalf("expected the input 31612345678 and wa_id 31612345678, got %+v", response.Contacts)
+	}
+}